@@ -0,0 +1,260 @@
+// Package beacon is a minimal client for the standard Beacon node API, as served by consensus-layer clients.
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// APIError is returned when the Beacon node answers with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *APIError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("beacon API error: %d", err.StatusCode)
+	}
+	return fmt.Sprintf("beacon API error: %d %s", err.StatusCode, err.Message)
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	specMutex     sync.Mutex
+	slotsPerEpoch uint64
+}
+
+// NewClient creates a Beacon API client for the node at baseURL. A nil httpClient uses http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// get queries path and decodes the data field of the response into result.
+func (c *Client) get(ctx context.Context, path string, result interface{}) error {
+	return c.do(ctx, http.MethodGet, path, nil, result)
+}
+
+// post sends body as JSON to path and decodes the data field of the response into result.
+func (c *Client) post(ctx context.Context, path string, body interface{}, result interface{}) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path, bytes.NewReader(encoded), result)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil {
+			apiErr.Message = body.Message
+		}
+		return apiErr
+	}
+
+	envelope := struct {
+		Data interface{} `json:"data"`
+	}{Data: result}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return errors.Wrapf(err, "failed to decode response of %s", path)
+	}
+	return nil
+}
+
+type SyncingStatus struct {
+	HeadSlot     uint64 `json:"head_slot,string"`
+	SyncDistance uint64 `json:"sync_distance,string"`
+	IsSyncing    bool   `json:"is_syncing"`
+	IsOptimistic bool   `json:"is_optimistic"`
+}
+
+// Syncing returns the node's sync status, from /eth/v1/node/syncing.
+func (c *Client) Syncing(ctx context.Context) (*SyncingStatus, error) {
+	var result SyncingStatus
+	if err := c.get(ctx, "/eth/v1/node/syncing", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type PeerCount struct {
+	Disconnected  uint64 `json:"disconnected,string"`
+	Connecting    uint64 `json:"connecting,string"`
+	Connected     uint64 `json:"connected,string"`
+	Disconnecting uint64 `json:"disconnecting,string"`
+}
+
+// PeerCount returns the number of peers by connection state, from /eth/v1/node/peer_count.
+func (c *Client) PeerCount(ctx context.Context) (*PeerCount, error) {
+	var result PeerCount
+	if err := c.get(ctx, "/eth/v1/node/peer_count", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type BlockHeader struct {
+	Root      string `json:"root"`
+	Canonical bool   `json:"canonical"`
+	Header    struct {
+		Message struct {
+			Slot          uint64 `json:"slot,string"`
+			ProposerIndex uint64 `json:"proposer_index,string"`
+		} `json:"message"`
+	} `json:"header"`
+}
+
+// BlockHeader returns the header of the block identified by blockID (head, finalized, a slot or a root), from
+// /eth/v1/beacon/headers/{block_id}.
+func (c *Client) BlockHeader(ctx context.Context, blockID string) (*BlockHeader, error) {
+	var result BlockHeader
+	if err := c.get(ctx, "/eth/v1/beacon/headers/"+blockID, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type Checkpoint struct {
+	Epoch uint64 `json:"epoch,string"`
+	Root  string `json:"root"`
+}
+
+type FinalityCheckpoints struct {
+	PreviousJustified Checkpoint `json:"previous_justified"`
+	CurrentJustified  Checkpoint `json:"current_justified"`
+	Finalized         Checkpoint `json:"finalized"`
+}
+
+// FinalityCheckpoints returns the finality checkpoints of the state identified by stateID, from
+// /eth/v1/beacon/states/{state_id}/finality_checkpoints.
+func (c *Client) FinalityCheckpoints(ctx context.Context, stateID string) (*FinalityCheckpoints, error) {
+	var result FinalityCheckpoints
+	if err := c.get(ctx, "/eth/v1/beacon/states/"+stateID+"/finality_checkpoints", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SlotsPerEpoch returns the SLOTS_PER_EPOCH value of the chain spec, from /eth/v1/config/spec. It is only queried once.
+func (c *Client) SlotsPerEpoch(ctx context.Context) (uint64, error) {
+	c.specMutex.Lock()
+	defer c.specMutex.Unlock()
+
+	if c.slotsPerEpoch != 0 {
+		return c.slotsPerEpoch, nil
+	}
+
+	var spec map[string]json.RawMessage
+	if err := c.get(ctx, "/eth/v1/config/spec", &spec); err != nil {
+		return 0, errors.Wrap(err, "failed to get chain spec")
+	}
+	var raw string
+	if err := json.Unmarshal(spec["SLOTS_PER_EPOCH"], &raw); err != nil {
+		return 0, errors.Wrap(err, "failed to read SLOTS_PER_EPOCH from chain spec")
+	}
+	slots, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || slots == 0 {
+		return 0, errors.Errorf("invalid SLOTS_PER_EPOCH %q in chain spec", raw)
+	}
+	c.slotsPerEpoch = slots
+	return slots, nil
+}
+
+type Validator struct {
+	Index     uint64 `json:"index,string"`
+	Balance   uint64 `json:"balance,string"`
+	Status    string `json:"status"`
+	Validator struct {
+		Pubkey           string `json:"pubkey"`
+		EffectiveBalance uint64 `json:"effective_balance,string"`
+		Slashed          bool   `json:"slashed"`
+	} `json:"validator"`
+}
+
+// Validators returns the validators identified by ids, either indices or public keys, in the state identified by
+// stateID, from /eth/v1/beacon/states/{state_id}/validators. Balances are in gwei.
+func (c *Client) Validators(ctx context.Context, stateID string, ids []string) ([]Validator, error) {
+	var result []Validator
+	path := "/eth/v1/beacon/states/" + stateID + "/validators?id=" + url.QueryEscape(strings.Join(ids, ","))
+	if err := c.get(ctx, path, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type ValidatorLiveness struct {
+	Index  uint64 `json:"index,string"`
+	IsLive bool   `json:"is_live"`
+}
+
+// Liveness returns whether the given validators were seen attesting or proposing in epoch, from
+// /eth/v1/validator/liveness/{epoch}. Nodes only answer for the current and previous epochs.
+func (c *Client) Liveness(ctx context.Context, epoch uint64, indices []uint64) ([]ValidatorLiveness, error) {
+	body := make([]string, len(indices))
+	for i, index := range indices {
+		body[i] = strconv.FormatUint(index, 10)
+	}
+	var result []ValidatorLiveness
+	if err := c.post(ctx, "/eth/v1/validator/liveness/"+strconv.FormatUint(epoch, 10), body, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type ProposerDuty struct {
+	Pubkey         string `json:"pubkey"`
+	ValidatorIndex uint64 `json:"validator_index,string"`
+	Slot           uint64 `json:"slot,string"`
+}
+
+// ProposerDuties returns the block proposers of every slot in epoch, from /eth/v1/validator/duties/proposer/{epoch}.
+func (c *Client) ProposerDuties(ctx context.Context, epoch uint64) ([]ProposerDuty, error) {
+	var result []ProposerDuty
+	if err := c.get(ctx, "/eth/v1/validator/duties/proposer/"+strconv.FormatUint(epoch, 10), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IsNotFound reports whether err is a 404 response, as returned for slots without a block.
+func IsNotFound(err error) bool {
+	apiErr, ok := errors.Cause(err).(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,172 @@
+// Package multicall aggregates contract view calls into few eth_calls through the Multicall3 contract, running them
+// at a single block for a consistent snapshot.
+package multicall
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// Address is the address Multicall3 is deployed at on most chains.
+var Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// maxCallsPerAggregate keeps the eth_calls of large aggregations below the gas and response size limits of
+// providers.
+const maxCallsPerAggregate = 500
+
+const multicall3ABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var parsedABI = mustParseABI(multicall3ABI)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// Call is a view call of a contract.
+type Call struct {
+	Target common.Address
+	Data   []byte
+}
+
+// Result is the outcome of a Call, whose ReturnData is only meaningful when it succeeded.
+type Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// call3 is the Call3 struct of Multicall3.
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Client reads contracts and the latest block number, such as an *ethclient.Client.
+type Client interface {
+	bind.ContractCaller
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// Aggregator runs calls through Multicall3, or one by one when the chain has no Multicall3 deployment.
+type Aggregator struct {
+	client  Client
+	address common.Address
+
+	mutex sync.Mutex
+	// deployed is only set once the deployment was checked.
+	deployed *bool
+}
+
+func NewAggregator(client Client) *Aggregator {
+	return &Aggregator{client: client, address: Address}
+}
+
+// Aggregate runs calls at the latest block, and returns that block along with the results of the calls, in order.
+func (a *Aggregator) Aggregate(ctx context.Context, calls []Call) (uint64, []Result, error) {
+	block, err := a.client.BlockNumber(ctx)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to get block number")
+	}
+	results, err := a.AggregateAt(ctx, block, calls)
+	return block, results, err
+}
+
+// AggregateAt runs calls at block, and returns their results in order.
+func (a *Aggregator) AggregateAt(ctx context.Context, block uint64, calls []Call) ([]Result, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	number := new(big.Int).SetUint64(block)
+	deployed, err := a.isDeployed(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if !deployed {
+		return a.callEach(ctx, number, calls)
+	}
+
+	results := make([]Result, 0, len(calls))
+	for start := 0; start < len(calls); start += maxCallsPerAggregate {
+		end := start + maxCallsPerAggregate
+		if end > len(calls) {
+			end = len(calls)
+		}
+		chunk, err := a.aggregate3(ctx, number, calls[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, chunk...)
+	}
+	return results, nil
+}
+
+// isDeployed returns whether Multicall3 is deployed, checking it on the first aggregation.
+func (a *Aggregator) isDeployed(ctx context.Context, block *big.Int) (bool, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.deployed != nil {
+		return *a.deployed, nil
+	}
+	code, err := a.client.CodeAt(ctx, a.address, block)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check the Multicall3 deployment")
+	}
+	deployed := len(code) > 0
+	a.deployed = &deployed
+	return deployed, nil
+}
+
+func (a *Aggregator) aggregate3(ctx context.Context, block *big.Int, calls []Call) ([]Result, error) {
+	args := make([]call3, len(calls))
+	for i, call := range calls {
+		args[i] = call3{Target: call.Target, AllowFailure: true, CallData: call.Data}
+	}
+	data, err := parsedABI.Pack("aggregate3", args)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack aggregate3")
+	}
+	output, err := a.client.CallContract(ctx, ethereum.CallMsg{To: &a.address, Data: data}, block)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to aggregate %d calls", len(calls))
+	}
+	out, err := parsedABI.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unpack aggregate3")
+	}
+	results := *abi.ConvertType(out[0], new([]Result)).(*[]Result)
+	if len(results) != len(calls) {
+		return nil, errors.Errorf("got %d aggregate3 results for %d calls", len(results), len(calls))
+	}
+	return results, nil
+}
+
+// callEach runs calls one by one. Calls failing with a JSON-RPC error, such as reverts, fail on their own, while any
+// other error fails the aggregation.
+func (a *Aggregator) callEach(ctx context.Context, block *big.Int, calls []Call) ([]Result, error) {
+	results := make([]Result, len(calls))
+	for i, call := range calls {
+		target := call.Target
+		output, err := a.client.CallContract(ctx, ethereum.CallMsg{To: &target, Data: call.Data}, block)
+		if err != nil {
+			if _, ok := err.(rpc.Error); ok {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to call %s", target.Hex())
+		}
+		results[i] = Result{Success: true, ReturnData: output}
+	}
+	return results, nil
+}
@@ -0,0 +1,135 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// revertError is a JSON-RPC error, as returned for reverted calls.
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+// mockClient echoes the call data of successful calls, and reverts calls to revertTarget. It serves aggregate3 when
+// deployed is set.
+type mockClient struct {
+	t            *testing.T
+	deployed     bool
+	revertTarget common.Address
+	block        uint64
+	// calls counts the eth_calls, and blocks records the block of each of them.
+	calls  int
+	blocks []uint64
+}
+
+func (c *mockClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.block, nil
+}
+
+func (c *mockClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if c.deployed && contract == Address {
+		return []byte{0x60}, nil
+	}
+	return nil, nil
+}
+
+func (c *mockClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.calls++
+	c.blocks = append(c.blocks, blockNumber.Uint64())
+	if *call.To != Address {
+		if *call.To == c.revertTarget {
+			return nil, revertError{}
+		}
+		return call.Data, nil
+	}
+
+	method := parsedABI.Methods["aggregate3"]
+	in, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		c.t.Fatalf("failed to unpack aggregate3: %v", err)
+	}
+	args := *abi.ConvertType(in[0], new([]call3)).(*[]call3)
+	results := make([]Result, len(args))
+	for i, arg := range args {
+		if !arg.AllowFailure {
+			c.t.Fatalf("call %d doesn't allow failure", i)
+		}
+		if arg.Target != c.revertTarget {
+			results[i] = Result{Success: true, ReturnData: arg.CallData}
+		}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func mockCalls(n int, revertTarget common.Address) []Call {
+	calls := make([]Call, n)
+	for i := range calls {
+		calls[i] = Call{Target: common.BigToAddress(big.NewInt(int64(i + 1))), Data: []byte{byte(i)}}
+	}
+	calls[1].Target = revertTarget
+	return calls
+}
+
+func checkResults(t *testing.T, calls []Call, results []Result, revertTarget common.Address) {
+	if len(results) != len(calls) {
+		t.Fatalf("got %d results, want %d", len(results), len(calls))
+	}
+	for i, result := range results {
+		if calls[i].Target == revertTarget {
+			if result.Success {
+				t.Fatalf("call %d succeeded, want it reverted", i)
+			}
+			continue
+		}
+		if !result.Success || !bytes.Equal(result.ReturnData, calls[i].Data) {
+			t.Fatalf("got %+v for call %d, want %x", result, i, calls[i].Data)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	revertTarget := common.HexToAddress("0xdead")
+	client := &mockClient{t: t, deployed: true, revertTarget: revertTarget, block: 1234}
+	aggregator := NewAggregator(client)
+
+	calls := mockCalls(maxCallsPerAggregate+10, revertTarget)
+	block, results, err := aggregator.Aggregate(context.Background(), calls)
+	if err != nil {
+		t.Fatalf("failed to aggregate: %v", err)
+	}
+	if block != 1234 {
+		t.Fatalf("got block %d, want 1234", block)
+	}
+	checkResults(t, calls, results, revertTarget)
+	if client.calls != 2 {
+		t.Fatalf("got %d eth_calls, want 2", client.calls)
+	}
+	for _, b := range client.blocks {
+		if b != 1234 {
+			t.Fatalf("got eth_call at block %d, want 1234", b)
+		}
+	}
+}
+
+func TestAggregateWithoutDeployment(t *testing.T) {
+	revertTarget := common.HexToAddress("0xdead")
+	client := &mockClient{t: t, revertTarget: revertTarget, block: 1234}
+	aggregator := NewAggregator(client)
+
+	calls := mockCalls(5, revertTarget)
+	_, results, err := aggregator.Aggregate(context.Background(), calls)
+	if err != nil {
+		t.Fatalf("failed to aggregate: %v", err)
+	}
+	checkResults(t, calls, results, revertTarget)
+	if client.calls != 5 {
+		t.Fatalf("got %d eth_calls, want 5", client.calls)
+	}
+}
@@ -4,18 +4,32 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"text/tabwriter"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	beaconclient "github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/multicall"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/admin"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/catalog"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/contracts/erc20"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/eth"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/instrumented"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/l2"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/net"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/discovery"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/provider"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/scrape"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/web"
 )
 
 var version = "undefined"
@@ -36,6 +50,12 @@ func main() {
 
 	configFile := flag.String("config", "", "path to config file")
 	ver := flag.Bool("v", false, "print version number and exit")
+	overrides := config.RegisterFlags(flag.CommandLine)
+	checkConfig := flag.Bool("check-config", false, "validate the config file and exit")
+	webConfigFile := flag.String("web.config.file", "", "path to the web config file enabling TLS or basic auth on the HTTP server")
+	backfillFile := flag.String("backfill-balances", "", "write balance history snapshots since start_block_number to this OpenMetrics file and exit")
+	backfillDays := flag.Uint64("backfill-days", 0, "backfill the balance history snapshots of this many past UTC days, along with the current one, instead of since start_block_number")
+	listCollectors := flag.Bool("list-collectors", false, "print the collectors along with their options and exit")
 
 	flag.Parse()
 	if len(flag.Args()) > 0 {
@@ -47,25 +67,94 @@ func main() {
 		os.Exit(0)
 	}
 
-	cfg, err := config.ParseConfigFromFile(*configFile)
+	if *listCollectors {
+		printCollectors(os.Stdout)
+		os.Exit(0)
+	}
+
+	cfg, err := config.LoadConfigFromFile(*configFile, overrides)
+	if *checkConfig {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if *webConfigFile != "" {
+			if _, err := web.LoadConfig(*webConfigFile); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("%s is valid\n", *configFile)
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Failed to read config file (%v): %v", *configFile, err)
+	}
+
+	balanceUnit, err := units.Parse(cfg.General.BalanceUnit, units.Ether)
 	if err != nil {
-		log.Fatalf("Failed to read config file (%v): %v", configFile, err)
+		log.Fatalf("invalid balance_unit: %v", err)
+	}
+
+	gasPriceUnit, err := units.Parse(cfg.General.GasPriceUnit, units.Gwei)
+	if err != nil {
+		log.Fatalf("invalid gas_price_unit: %v", err)
 	}
 
 	// Initiate clients
-	rpc, err := rpc.Dial(cfg.General.EthProviderURL)
+	rpcMetrics := provider.NewMetrics(cfg.General.EthBlockchainName)
+	var rollupRPC *rpc.Client
+	if cfg.General.L2RollupURL != "" {
+		rollupRPC, err = provider.Dial(cfg.General.L2RollupURL, cfg.Providers.L2Rollup, rpcMetrics.Provider("l2_rollup"))
+		if err != nil {
+			log.Fatalf("failed to create rollup RPC client: %v", err)
+		}
+	}
+
+	rpc, err := provider.Dial(cfg.General.EthProviderURL, cfg.Providers.Eth, rpcMetrics.Provider("eth"))
 	if err != nil {
 		log.Fatalf("failed to create RPC client: %v", err)
 	}
 
-	client, err := ethclient.Dial(cfg.General.EthProviderURL)
-	if err != nil {
-		log.Fatalf("failed to create ETH client: %v", err)
+	client := ethclient.NewClient(rpc)
+	// the simple eth collectors and the balance collector share batch requests, and the results of concurrent
+	// scrapes
+	batcher := provider.NewBatcher(rpc, int(cfg.General.EthMaxBatchSize))
+	cache := provider.NewCache(batcher, cfg.General.EthCacheTTL)
+	// contract reads are aggregated through Multicall3
+	aggregator := multicall.NewAggregator(client)
+
+	if cfg.General.EthChainID != 0 {
+		chainID, err := eth.ChainID(context.Background(), rpc)
+		if err != nil {
+			log.Fatalf("failed to get chain id: %v", err)
+		}
+		if !chainID.IsUint64() || chainID.Uint64() != cfg.General.EthChainID {
+			log.Fatalf("provider serves chain id %s, but eth_chain_id is %d for %q", chainID, cfg.General.EthChainID, cfg.General.EthBlockchainName)
+		}
+	}
+
+	// Head up to which events are indexed
+	var eventHead erc20.BlockNumberGetter = client
+	switch cfg.General.EventBlockTag {
+	case "", eth.LatestBlockTag:
+		if cfg.General.EventConfirmations > 0 {
+			eventHead = erc20.NewConfirmedBlockNumber(client, cfg.General.EventConfirmations)
+		}
+	case eth.SafeBlockTag, eth.FinalizedBlockTag:
+		if cfg.General.EventConfirmations > 0 {
+			log.Fatalf("event_block_tag and event_confirmations are mutually exclusive")
+		}
+		eventHead = erc20.NewTaggedBlockNumber(cache, cfg.General.EventBlockTag)
+	default:
+		log.Fatalf("invalid event_block_tag %q, must be latest, safe or finalized", cfg.General.EventBlockTag)
 	}
 
+	// the start block is replaced by the current one when unset, from which there is nothing to backfill
+	backfillFrom := cfg.General.StartBlockNumber
 	if cfg.General.StartBlockNumber == 0 {
 		log.Printf("Setting startBlockNumber to current block num")
-		lastBlock, err := client.BlockNumber(context.Background())
+		lastBlock, err := eventHead.BlockNumber(context.Background())
 		if err != nil {
 			log.Fatalf("failed to get last block number: %v", err)
 		}
@@ -73,43 +162,218 @@ func main() {
 		cfg.General.StartBlockNumber = lastBlock
 	}
 
+	// Target sources
+	targets := cfg.Target
+	var targetDiscovery *discovery.Discovery
+	if len(cfg.TargetSources) > 0 {
+		targetDiscovery, err = discovery.New(cfg.TargetSources, cfg.General.EthChainID, &http.Client{Timeout: discovery.RefreshTimeout})
+		if err != nil {
+			log.Fatalf("failed to create target sources: %v", err)
+		}
+		targetDiscovery.Refresh(context.Background())
+		targets = discovery.Merge(cfg.Target, targetDiscovery.Targets())
+	}
+
 	// ERC-20 Targets
-	log.Printf("Detected %d ERC-20 smart contract(s) to monitor\n", len(cfg.Target.ERC20))
+	log.Printf("Detected %d ERC-20 smart contract(s) to monitor\n", len(targets.ERC20))
 
-	collectorTransferEvents, err := erc20.NewERC20TransferEvent(client, cfg.Target.ERC20, cfg.General.StartBlockNumber, cfg.General.EthBlockchainName)
-	if err != nil {
-		log.Fatalf("failed to create erc20 transfer collector: %v", err)
+	// Collectors of disabled targets are left nil, the event collectors fetching their contracts on creation
+	var collectorTransferEvents *erc20.TransferEvent
+	if cfg.CollectorEnabled("erc20_transfer_events") {
+		collectorTransferEvents, err = erc20.NewERC20TransferEvent(client, aggregator, eventHead, targets.ERC20, cfg.General.StartBlockNumber, cfg.General.EthBlockchainName)
+		if err != nil {
+			log.Fatalf("failed to create erc20 transfer collector: %v", err)
+		}
 	}
 
-	collectorApprovalEvents, err := erc20.NewERC20ApprovalEvent(client, cfg.Target.ERC20, cfg.General.StartBlockNumber, cfg.General.EthBlockchainName)
-	if err != nil {
-		log.Fatalf("failed to create erc20 approval collector: %v", err)
+	var collectorApprovalEvents *erc20.ApprovalEvent
+	if cfg.CollectorEnabled("erc20_approval_events") {
+		collectorApprovalEvents, err = erc20.NewERC20ApprovalEvent(client, aggregator, eventHead, targets.ERC20, cfg.General.StartBlockNumber, cfg.General.EthBlockchainName)
+		if err != nil {
+			log.Fatalf("failed to create erc20 approval collector: %v", err)
+		}
+	}
+
+	var collectorTokens *erc20.Tokens
+	if cfg.CollectorEnabled("erc20_tokens") {
+		collectorTokens, err = erc20.NewERC20Tokens(aggregator, targets.ERC20, targets.Wallets, cfg.General.EthBlockchainName)
+		if err != nil {
+			log.Fatalf("failed to create erc20 tokens collector: %v", err)
+		}
 	}
 
 	// Wallets  Target
-	collectorGetAddressBalance := eth.NewEthGetBalance(rpc, cfg.Target.Wallets, cfg.General.EthBlockchainName)
-
-	registry := prometheus.NewPedanticRegistry()
-	registry.MustRegister(
-		net.NewNetPeerCount(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthBlockNumber(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthBlockTimestamp(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthGasPrice(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthEarliestBlockTransactions(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthLatestBlockTransactions(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthPendingBlockTransactions(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthHashrate(rpc, cfg.General.EthBlockchainName),
-		eth.NewEthSyncing(rpc, cfg.General.EthBlockchainName),
-		collectorTransferEvents,
-		collectorGetAddressBalance,
-		collectorApprovalEvents,
-	)
-
-	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+	var collectorGetAddressBalance *eth.EthGetBalance
+	if cfg.CollectorEnabled("balance") {
+		collectorGetAddressBalance = eth.NewEthGetBalance(cache, targets.Wallets, cfg.CollectorUnit("balance", balanceUnit),
+			cfg.CollectorBool("balance", "export_exact_values", cfg.General.ExportExactValues), cfg.General.EthBlockchainName)
+	}
+	var collectorWalletBalanceThreshold *eth.EthWalletBalanceThreshold
+	if cfg.CollectorEnabled("wallet_balance_threshold") {
+		collectorWalletBalanceThreshold = eth.NewEthWalletBalanceThreshold(targets.Wallets, cfg.CollectorUnit("wallet_balance_threshold", balanceUnit), cfg.General.EthBlockchainName)
+	}
+
+	// Balance history
+	var collectorBalanceSnapshot *eth.EthBalanceSnapshot
+	if cfg.CollectorEnabled("balance_snapshot") {
+		collectorBalanceSnapshot = eth.NewEthBalanceSnapshot(cache, targets.Wallets, cfg.BalanceHistory.BlockInterval, cfg.BalanceHistory.Daily,
+			cfg.CollectorUnit("balance_snapshot", balanceUnit), cfg.General.EthBlockchainName)
+	}
+
+	if *backfillFile != "" {
+		if collectorBalanceSnapshot == nil {
+			log.Fatalf("balance_history must be configured, and the balance_snapshot collector enabled, to backfill balances")
+		}
+		if backfillFrom == 0 && *backfillDays == 0 {
+			log.Fatalf("start_block_number or -backfill-days must be set to backfill balances")
+		}
+		if err := backfillBalances(collectorBalanceSnapshot, backfillFrom, *backfillDays, *backfillFile); err != nil {
+			log.Fatalf("failed to backfill balances: %v", err)
+		}
+		log.Printf("Wrote balance snapshots to %s\n", *backfillFile)
+		os.Exit(0)
+	}
+
+	registry := scrape.NewRegistry()
+	registry.MustRegister(rpcMetrics)
+	// register wraps enabled collectors to export their scrape duration and success
+	register := func(name string, collector prometheus.Collector) {
+		if cfg.CollectorEnabled(name) {
+			registry.MustRegister(instrumented.NewCollector(name, collector, cfg.Collectors[name].Timeout, cfg.General.EthBlockchainName))
+		}
+	}
+	register("net_peers", net.NewNetPeerCount(rpc, cfg.General.EthBlockchainName))
+	register("client_info", eth.NewEthClientInfo(cache, cfg.General.EthBlockchainName))
+	register("block_number", eth.NewEthBlockNumber(cache, cfg.General.EthBlockchainName))
+	register("block_timestamp", eth.NewEthBlockTimestamp(cache, cfg.General.EthBlockchainName))
+	register("gas_price", eth.NewEthGasPrice(cache, cfg.CollectorUnit("gas_price", gasPriceUnit),
+		cfg.CollectorBool("gas_price", "export_exact_values", cfg.General.ExportExactValues), cfg.General.EthBlockchainName))
+	register("earliest_block_transactions", eth.NewEthEarliestBlockTransactions(cache, cfg.General.EthBlockchainName))
+	register("latest_block_transactions", eth.NewEthLatestBlockTransactions(cache, cfg.General.EthBlockchainName))
+	register("pending_block_transactions", eth.NewEthPendingBlockTransactions(cache, cfg.General.EthBlockchainName))
+	register("hashrate", eth.NewEthHashrate(cache, cfg.General.EthBlockchainName))
+	register("syncing", eth.NewEthSyncing(cache, cfg.General.EthBlockchainName))
+	if collectorTransferEvents != nil {
+		register("erc20_transfer_events", collectorTransferEvents)
+	}
+	if collectorGetAddressBalance != nil {
+		register("balance", collectorGetAddressBalance)
+	}
+	if collectorWalletBalanceThreshold != nil {
+		register("wallet_balance_threshold", collectorWalletBalanceThreshold)
+	}
+	if collectorApprovalEvents != nil {
+		register("erc20_approval_events", collectorApprovalEvents)
+	}
+	if collectorBalanceSnapshot != nil {
+		register("balance_snapshot", collectorBalanceSnapshot)
+	}
+	if collectorTokens != nil {
+		register("erc20_tokens", collectorTokens)
+	}
+
+	// Admin API, only enabled by default along with admin_api
+	register("admin_peers", admin.NewAdminPeers(rpc, cfg.General.EthBlockchainName))
+	register("admin_node_info", admin.NewAdminNodeInfo(rpc, cfg.General.EthBlockchainName))
+
+	configReloader := &reloader{
+		path:              *configFile,
+		overrides:         overrides,
+		registry:          registry,
+		transferEvents:    collectorTransferEvents,
+		approvalEvents:    collectorApprovalEvents,
+		tokens:            collectorTokens,
+		balance:           collectorGetAddressBalance,
+		balanceThreshold:  collectorWalletBalanceThreshold,
+		balanceSnapshot:   collectorBalanceSnapshot,
+		balanceUnit:       cfg.CollectorUnit("beacon_validators", balanceUnit),
+		validatorsEnabled: cfg.CollectorEnabled("beacon_validators"),
+		validatorsTimeout: cfg.Collectors["beacon_validators"].Timeout,
+		blockchain:        cfg.General.EthBlockchainName,
+		static:            cfg.Target,
+		discovery:         targetDiscovery,
+	}
+
+	// Consensus layer
+	if cfg.General.BeaconProviderURL != "" {
+		beaconHTTPClient, err := provider.NewHTTPClient(cfg.Providers.Beacon, nil)
+		if err != nil {
+			log.Fatalf("failed to create Beacon API client: %v", err)
+		}
+		beaconClient := beaconclient.NewClient(cfg.General.BeaconProviderURL, beaconHTTPClient)
+		register("beacon_syncing", beacon.NewBeaconSyncing(beaconClient, cfg.General.EthBlockchainName))
+		register("beacon_peers", beacon.NewBeaconPeerCount(beaconClient, cfg.General.EthBlockchainName))
+		register("beacon_head_slot", beacon.NewBeaconHeadSlot(beaconClient, cfg.General.EthBlockchainName))
+		register("beacon_finality", beacon.NewBeaconFinality(beaconClient, cfg.General.EthBlockchainName))
+		configReloader.beaconClient = beaconClient
+
+		if len(targets.Validators) > 0 && configReloader.validatorsEnabled {
+			log.Printf("Detected %d validator(s) to monitor\n", len(targets.Validators))
+			if err := configReloader.registerValidators(targets.Validators); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+	} else if len(targets.Validators) > 0 {
+		log.Fatalf("beacon_provider_url must be configured to monitor validators")
+	}
+
+	// L2 chains
+	switch cfg.General.L2Type {
+	case "":
+	case "optimism":
+		register("optimism_l1_fees", l2.NewOptimismL1Fees(rpc, cfg.CollectorUnit("optimism_l1_fees", balanceUnit), gasPriceUnit, cfg.General.EthBlockchainName))
+		if rollupRPC != nil {
+			register("optimism_sync_status", l2.NewOptimismSyncStatus(rollupRPC, cfg.General.EthBlockchainName))
+		}
+	case "arbitrum":
+		register("arbitrum_l1_fees", l2.NewArbitrumL1Fees(rpc, cfg.General.EthBlockchainName))
+		register("arbitrum_batch_posting", l2.NewArbitrumBatchPosting(rpc, cfg.General.EthBlockchainName))
+	default:
+		log.Fatalf("invalid l2_type %q, must be optimism or arbitrum", cfg.General.L2Type)
+	}
+
+	handler := registry.Handler(promhttp.HandlerOpts{
 		ErrorLog:      log.New(os.Stderr, log.Prefix(), log.Flags()),
 		ErrorHandling: promhttp.ContinueOnError,
 	})
 
 	http.Handle("/metrics", handler)
-	log.Fatal(http.ListenAndServe(cfg.General.ServerURL, nil))
+	http.Handle("/-/reload", configReloader)
+	go configReloader.watchSignals()
+	if targetDiscovery != nil {
+		go targetDiscovery.Run(context.Background(), configReloader.applyDiscovered)
+	}
+
+	log.Fatal(web.ListenAndServe(cfg.General.ServerURL, *webConfigFile, http.DefaultServeMux))
+}
+
+// backfillBalances writes the balance snapshots since from, or since the start of the UTC day days ago when set.
+func backfillBalances(collector *eth.EthBalanceSnapshot, from, days uint64, path string) error {
+	if days > 0 {
+		var err error
+		if from, err = collector.DayStartBlock(context.Background(), days); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := collector.Backfill(context.Background(), from, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printCollectors writes the collectors along with their options.
+func printCollectors(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, collector := range catalog.Collectors {
+		fmt.Fprintf(tw, "%s\t%s\n", collector.Name, collector.Help)
+		for _, option := range collector.Options {
+			fmt.Fprintf(tw, "  %s (%s)\t%s\n", option.Name, option.Kind, option.Help)
+		}
+	}
+	tw.Flush()
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	beaconclient "github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/contracts/erc20"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/eth"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/instrumented"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/discovery"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/scrape"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+// reloader re-reads the config file and applies its targets, along with the discovered ones, to the running
+// collectors. Collectors keep the state of the targets that didn't change, such as event cursors and counters.
+// Changes to any other section require a restart.
+type reloader struct {
+	path      string
+	overrides config.Overrides
+	registry  *scrape.Registry
+	mutex     sync.Mutex
+
+	// The target collectors are only set when enabled.
+	transferEvents   *erc20.TransferEvent
+	approvalEvents   *erc20.ApprovalEvent
+	tokens           *erc20.Tokens
+	balance          *eth.EthGetBalance
+	balanceThreshold *eth.EthWalletBalanceThreshold
+	balanceSnapshot  *eth.EthBalanceSnapshot
+
+	// beaconClient is only set when a Beacon API is configured, and validators, along with the registered
+	// validatorsCollector wrapping it, while there are validators to monitor and the collector is enabled.
+	beaconClient        *beaconclient.Client
+	validatorsEnabled   bool
+	validatorsTimeout   time.Duration
+	validators          *beacon.BeaconValidators
+	validatorsCollector *instrumented.Collector
+	balanceUnit         units.Unit
+	blockchain          string
+
+	// static holds the targets of the config file last applied, and discovery, only set when target sources are configured,
+	// the discovered ones.
+	static    config.Targets
+	discovery *discovery.Discovery
+}
+
+func (r *reloader) reload() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cfg, err := config.LoadConfigFromFile(r.path, r.overrides)
+	if err != nil {
+		return err
+	}
+
+	targets := cfg.Target
+	if r.discovery != nil {
+		targets = discovery.Merge(cfg.Target, r.discovery.Targets())
+	}
+	if err := r.apply(targets); err != nil {
+		return err
+	}
+	r.static = cfg.Target
+	return nil
+}
+
+// applyDiscovered applies the targets of the config file along with newly discovered ones.
+func (r *reloader) applyDiscovered(discovered config.Targets) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.apply(discovery.Merge(r.static, discovered)); err != nil {
+		log.Printf("Failed to apply discovered targets: %v", err)
+	}
+}
+
+// apply applies targets to every collector, or to none of them when one fails to read the info of its new targets.
+func (r *reloader) apply(targets config.Targets) error {
+	if r.beaconClient == nil && len(targets.Validators) > 0 {
+		return errors.New("beacon_provider_url must be configured to monitor validators")
+	}
+
+	var commits []func()
+	if r.transferEvents != nil {
+		commit, err := r.transferEvents.PrepareTargets(targets.ERC20)
+		if err != nil {
+			return errors.Wrap(err, "failed to reload erc20 transfer collector")
+		}
+		commits = append(commits, commit)
+	}
+	if r.approvalEvents != nil {
+		commit, err := r.approvalEvents.PrepareTargets(targets.ERC20)
+		if err != nil {
+			return errors.Wrap(err, "failed to reload erc20 approval collector")
+		}
+		commits = append(commits, commit)
+	}
+	if r.tokens != nil {
+		commit, err := r.tokens.PrepareTargets(targets.ERC20, targets.Wallets)
+		if err != nil {
+			return errors.Wrap(err, "failed to reload erc20 tokens collector")
+		}
+		commits = append(commits, commit)
+	}
+
+	// registering the validators collector is the last step that can fail, so it runs before any target is replaced
+	switch {
+	case !r.validatorsEnabled:
+	case r.validators != nil && len(targets.Validators) > 0:
+		r.validators.SetValidators(targets.Validators)
+	case r.validators != nil:
+		r.registry.Unregister(r.validatorsCollector)
+		r.validators, r.validatorsCollector = nil, nil
+	case len(targets.Validators) > 0:
+		if err := r.registerValidators(targets.Validators); err != nil {
+			return err
+		}
+	}
+
+	for _, commit := range commits {
+		commit()
+	}
+	if r.balance != nil {
+		r.balance.SetWallets(targets.Wallets)
+	}
+	if r.balanceThreshold != nil {
+		r.balanceThreshold.SetWallets(targets.Wallets)
+	}
+	if r.balanceSnapshot != nil {
+		r.balanceSnapshot.SetWallets(targets.Wallets)
+	}
+
+	log.Printf("Monitoring %d ERC-20 smart contract(s), %d wallet(s) and %d validator(s)\n",
+		len(targets.ERC20), len(targets.Wallets), len(targets.Validators))
+	return nil
+}
+
+// registerValidators registers a collector monitoring validators.
+func (r *reloader) registerValidators(validators []config.ValidatorTarget) error {
+	collector := beacon.NewBeaconValidators(r.beaconClient, validators, r.balanceUnit, r.blockchain)
+	wrapped := instrumented.NewCollector("beacon_validators", collector, r.validatorsTimeout, r.blockchain)
+	if err := r.registry.Register(wrapped); err != nil {
+		return errors.Wrap(err, "failed to register validators collector")
+	}
+	r.validators, r.validatorsCollector = collector, wrapped
+	return nil
+}
+
+// watchSignals reloads the config on every SIGHUP.
+func (r *reloader) watchSignals() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if err := r.reload(); err != nil {
+			log.Printf("Failed to reload config: %v", err)
+		}
+	}
+}
+
+// ServeHTTP reloads the config on POST or PUT requests, like Prometheus' /-/reload endpoint.
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+		http.Error(w, "Only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.reload(); err != nil {
+		log.Printf("Failed to reload config: %v", err)
+		http.Error(w, fmt.Sprintf("failed to reload config: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	clientLabel = "client"
+
+	clientGeth       = "geth"
+	clientNethermind = "nethermind"
+	clientErigon     = "erigon"
+	clientBesu       = "besu"
+	clientReth       = "reth"
+	clientOpenEth    = "openethereum"
+	clientOther      = "other"
+	clientUnknown    = "unknown"
+)
+
+// knownClients are the client names used as label values, so that peers can't grow the label set.
+var knownClients = map[string]bool{
+	clientGeth:       true,
+	clientNethermind: true,
+	clientErigon:     true,
+	clientBesu:       true,
+	clientReth:       true,
+	clientOpenEth:    true,
+}
+
+// clientName returns the lowercase client name from a client version string such as
+// "Geth/v1.10.16-stable/linux-amd64/go1.17", or "other" for clients that aren't known.
+func clientName(version string) string {
+	name := strings.ToLower(strings.SplitN(version, "/", 2)[0])
+	if name == "" {
+		return clientUnknown
+	}
+	if !knownClients[name] {
+		return clientOther
+	}
+	return name
+}
+
+// clientVersion returns the web3_clientVersion of the node.
+func clientVersion(ctx context.Context, rpc *rpc.Client) (string, error) {
+	var version string
+	if err := rpc.CallContext(ctx, &version, "web3_clientVersion"); err != nil {
+		return "", err
+	}
+	return version, nil
+}
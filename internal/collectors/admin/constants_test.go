@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const mockBlockchainName = "test_blockchain"
+
+// newMockRPCServer answers each JSON-RPC method with the given raw result, and with a method not found error for any
+// other method.
+func newMockRPCServer(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Method string
+			Params []json.RawMessage
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %#v", err)
+		}
+
+		response := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": -32601, "message": "the method %s does not exist/is not available"}}`, req.ID, req.Method)
+		if result, ok := results[req.Method]; ok {
+			response = fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": %s}`, req.ID, result)
+		}
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/rpcerr"
+)
+
+const (
+	clientVersionLabel = "client_version"
+	idLabel            = "id"
+)
+
+type nodeInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Protocols struct {
+		Eth *struct {
+			Network *big.Int `json:"network"`
+			Config  *struct {
+				ChainID *big.Int `json:"chainId"`
+			} `json:"config"`
+		} `json:"eth"`
+	} `json:"protocols"`
+}
+
+// AdminNodeInfo exports the node identity from admin_nodeInfo, and whether the chain and network ids it reports
+// match the ones served through eth_chainId and net_version. Nodes that don't expose the admin namespace produce no
+// metrics.
+type AdminNodeInfo struct {
+	rpc                 *rpc.Client
+	infoDesc            *prometheus.Desc
+	chainMismatchDesc   *prometheus.Desc
+	networkMismatchDesc *prometheus.Desc
+}
+
+func NewAdminNodeInfo(rpc *rpc.Client, blockchain string) *AdminNodeInfo {
+	return &AdminNodeInfo{
+		rpc: rpc,
+		infoDesc: prometheus.NewDesc(
+			"admin_node_info",
+			"node identity, as reported by admin_nodeInfo",
+			[]string{clientLabel, clientVersionLabel, idLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		chainMismatchDesc: prometheus.NewDesc(
+			"admin_chain_id_mismatch",
+			"whether the chain id in admin_nodeInfo differs from eth_chainId",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		networkMismatchDesc: prometheus.NewDesc(
+			"admin_network_id_mismatch",
+			"whether the network id in admin_nodeInfo differs from net_version",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *AdminNodeInfo) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.infoDesc
+	ch <- collector.chainMismatchDesc
+	ch <- collector.networkMismatchDesc
+}
+
+func mismatch(a, b *big.Int) float64 {
+	if a.Cmp(b) != 0 {
+		return 1
+	}
+	return 0
+}
+
+func (collector *AdminNodeInfo) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *AdminNodeInfo) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	var info nodeInfo
+	if err := collector.rpc.CallContext(ctx, &info, "admin_nodeInfo"); err != nil {
+		if !rpcerr.IsMethodNotFound(err) {
+			ch <- prometheus.NewInvalidMetric(collector.infoDesc, err)
+		}
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(collector.infoDesc, prometheus.GaugeValue, 1, clientName(info.Name), info.Name, info.ID)
+
+	eth := info.Protocols.Eth
+	if eth == nil {
+		return
+	}
+
+	if eth.Config != nil && eth.Config.ChainID != nil {
+		var chainID hexutil.Big
+		if err := collector.rpc.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+			ch <- prometheus.NewInvalidMetric(collector.chainMismatchDesc, err)
+		} else {
+			ch <- prometheus.MustNewConstMetric(collector.chainMismatchDesc, prometheus.GaugeValue, mismatch(eth.Config.ChainID, chainID.ToInt()))
+		}
+	}
+
+	if eth.Network != nil {
+		var netVersion string
+		if err := collector.rpc.CallContext(ctx, &netVersion, "net_version"); err != nil {
+			ch <- prometheus.NewInvalidMetric(collector.networkMismatchDesc, err)
+			return
+		}
+		networkID, err := strconv.ParseUint(netVersion, 10, 64)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(collector.networkMismatchDesc, err)
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(collector.networkMismatchDesc, prometheus.GaugeValue, mismatch(eth.Network, new(big.Int).SetUint64(networkID)))
+	}
+}
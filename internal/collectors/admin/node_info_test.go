@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestAdminNodeInfoCollect(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]string{
+		"admin_nodeInfo": `{
+			"id": "44826a5d6a55f88a18298bca4773fca5749cdc3a5c9f308aa7d810e9b31123f3",
+			"name": "Geth/v1.10.16-stable/linux-amd64/go1.17",
+			"protocols": {"eth": {"network": 1, "config": {"chainId": 5}}}
+		}`,
+		"eth_chainId": `"0x1"`,
+		"net_version": `"1"`,
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewAdminNodeInfo(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %v, want 3", got)
+	}
+
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := len(metric.Label); got != 4 {
+		t.Fatalf("expected 4 labels, got %d", got)
+	}
+	if got := metric.Label[1].GetValue(); got != clientGeth {
+		t.Fatalf("got %v, want %v", got, clientGeth)
+	}
+
+	// the node reports chain id 5 while eth_chainId says 1, and matching network ids
+	for _, want := range []float64{1, 0} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestAdminNodeInfoCollectNotExposed(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]string{})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewAdminNodeInfo(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 0 {
+		t.Fatalf("got %v, want 0", got)
+	}
+}
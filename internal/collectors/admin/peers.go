@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/rpcerr"
+)
+
+const (
+	directionLabel = "direction"
+	protocolLabel  = "protocol"
+	versionLabel   = "version"
+)
+
+type peerInfo struct {
+	Name    string `json:"name"`
+	Network struct {
+		Inbound bool `json:"inbound"`
+	} `json:"network"`
+	Protocols map[string]json.RawMessage `json:"protocols"`
+	// ClientID is reported instead of Name by older Nethermind versions.
+	ClientID string `json:"clientId"`
+}
+
+type protocolInfo struct {
+	Version uint `json:"version"`
+}
+
+// AdminPeers exports the composition of the node's peers, from admin_peers. Nodes that don't expose the admin
+// namespace produce no metrics.
+type AdminPeers struct {
+	rpc          *rpc.Client
+	peersDesc    *prometheus.Desc
+	clientsDesc  *prometheus.Desc
+	protocolDesc *prometheus.Desc
+}
+
+func NewAdminPeers(rpc *rpc.Client, blockchain string) *AdminPeers {
+	return &AdminPeers{
+		rpc: rpc,
+		peersDesc: prometheus.NewDesc(
+			"admin_peers",
+			"number of peers by connection direction",
+			[]string{directionLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		clientsDesc: prometheus.NewDesc(
+			"admin_peers_by_client",
+			"number of peers by client",
+			[]string{clientLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		protocolDesc: prometheus.NewDesc(
+			"admin_peers_by_protocol",
+			"number of peers by negotiated protocol version",
+			[]string{protocolLabel, versionLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *AdminPeers) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.peersDesc
+	ch <- collector.clientsDesc
+	ch <- collector.protocolDesc
+}
+
+func (collector *AdminPeers) invalid(ch chan<- prometheus.Metric, err error) {
+	ch <- prometheus.NewInvalidMetric(collector.peersDesc, err)
+	ch <- prometheus.NewInvalidMetric(collector.clientsDesc, err)
+	ch <- prometheus.NewInvalidMetric(collector.protocolDesc, err)
+}
+
+func (collector *AdminPeers) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *AdminPeers) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	version, err := clientVersion(ctx, collector.rpc)
+	if err != nil {
+		collector.invalid(ch, err)
+		return
+	}
+
+	// Nethermind only includes peer details when asked to.
+	var args []interface{}
+	if clientName(version) == clientNethermind {
+		args = append(args, true)
+	}
+
+	var peers []peerInfo
+	if err := collector.rpc.CallContext(ctx, &peers, "admin_peers", args...); err != nil {
+		if !rpcerr.IsMethodNotFound(err) {
+			collector.invalid(ch, err)
+		}
+		return
+	}
+
+	directions := map[string]int{"inbound": 0, "outbound": 0}
+	clients := map[string]int{}
+	protocols := map[[2]string]int{}
+	for _, peer := range peers {
+		if peer.Network.Inbound {
+			directions["inbound"]++
+		} else {
+			directions["outbound"]++
+		}
+
+		name := peer.Name
+		if name == "" {
+			name = peer.ClientID
+		}
+		clients[clientName(name)]++
+
+		for protocol, raw := range peer.Protocols {
+			var info protocolInfo
+			// protocols that are still handshaking are reported as a plain string
+			if err := json.Unmarshal(raw, &info); err != nil || info.Version == 0 {
+				continue
+			}
+			protocols[[2]string{protocol, fmt.Sprint(info.Version)}]++
+		}
+	}
+
+	for direction, count := range directions {
+		ch <- prometheus.MustNewConstMetric(collector.peersDesc, prometheus.GaugeValue, float64(count), direction)
+	}
+	for client, count := range clients {
+		ch <- prometheus.MustNewConstMetric(collector.clientsDesc, prometheus.GaugeValue, float64(count), client)
+	}
+	for protocol, count := range protocols {
+		ch <- prometheus.MustNewConstMetric(collector.protocolDesc, prometheus.GaugeValue, float64(count), protocol[0], protocol[1])
+	}
+}
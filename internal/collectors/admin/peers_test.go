@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestClientName(t *testing.T) {
+	cases := map[string]string{
+		"Geth/v1.10.16-stable/linux-amd64/go1.17":           clientGeth,
+		"Nethermind/v1.12.7+2b5f6b8e/linux-x64/dotnet6.0.1": clientNethermind,
+		"erigon/2022.02.2/linux-amd64/go1.17.7":             clientErigon,
+		"besu/v22.1.0/linux-x86_64/openjdk-java-11":         clientBesu,
+		"reth/v0.1.0-alpha.10/x86_64-unknown-linux-gnu":     clientReth,
+		"MyCustomClient/v0.0.1/linux-amd64":                 clientOther,
+		"":                                                  clientUnknown,
+	}
+	for version, want := range cases {
+		if got := clientName(version); got != want {
+			t.Fatalf("got %v, want %v for %q", got, want, version)
+		}
+	}
+}
+
+func TestAdminPeersCollectNotExposed(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]string{
+		"web3_clientVersion": `"Geth/v1.10.16-stable/linux-amd64/go1.17"`,
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewAdminPeers(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 0 {
+		t.Fatalf("got %v, want 0", got)
+	}
+}
+
+func TestAdminPeersCollect(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]string{
+		"web3_clientVersion": `"Geth/v1.10.16-stable/linux-amd64/go1.17"`,
+		"admin_peers": `[
+			{"name": "Geth/v1.10.15-stable/linux-amd64/go1.17.5", "network": {"inbound": true}, "protocols": {"eth": {"version": 66}, "snap": {"version": 1}}},
+			{"name": "Geth/v1.10.16-stable/linux-amd64/go1.17", "network": {"inbound": false}, "protocols": {"eth": {"version": 66}, "snap": "handshake"}},
+			{"name": "erigon/2022.02.2/linux-amd64/go1.17.7", "network": {"inbound": false}, "protocols": {"eth": {"version": 65}}}
+		]`,
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewAdminPeers(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 7)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 7 {
+		t.Fatalf("got %v, want 7", got)
+	}
+
+	expected := map[string]float64{
+		"inbound":  1,
+		"outbound": 2,
+		"geth":     2,
+		"erigon":   1,
+		"eth/66":   2,
+		"eth/65":   1,
+		"snap/1":   1,
+	}
+	for result := range ch {
+		var metric dto.Metric
+		if err := result.Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		key := metric.Label[1].GetValue()
+		if len(metric.Label) == 3 {
+			key = metric.Label[1].GetValue() + "/" + metric.Label[2].GetValue()
+		}
+		if got := *metric.Gauge.Value; got != expected[key] {
+			t.Fatalf("got %v, want %v for %s", got, expected[key], key)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package beacon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const mockBlockchainName = "test_blockchain"
+
+// newMockBeaconServer serves the given response bodies by request path, and 404 for anything else.
+func newMockBeaconServer(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"code": 404, "message": "not found"}`
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+}
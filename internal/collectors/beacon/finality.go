@@ -0,0 +1,78 @@
+package beacon
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+)
+
+type BeaconFinality struct {
+	client        *beacon.Client
+	finalizedDesc *prometheus.Desc
+	justifiedDesc *prometheus.Desc
+	distanceDesc  *prometheus.Desc
+}
+
+func NewBeaconFinality(client *beacon.Client, blockchain string) *BeaconFinality {
+	return &BeaconFinality{
+		client: client,
+		finalizedDesc: prometheus.NewDesc(
+			"beacon_finalized_epoch",
+			"epoch of the latest finalized checkpoint",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		justifiedDesc: prometheus.NewDesc(
+			"beacon_justified_epoch",
+			"epoch of the current justified checkpoint",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		distanceDesc: prometheus.NewDesc(
+			"beacon_finality_distance",
+			"number of epochs between the head and the latest finalized checkpoint",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *BeaconFinality) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.finalizedDesc
+	ch <- collector.justifiedDesc
+	ch <- collector.distanceDesc
+}
+
+func (collector *BeaconFinality) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *BeaconFinality) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	checkpoints, err := collector.client.FinalityCheckpoints(ctx, "head")
+	if err != nil {
+		wErr := errors.Wrap(err, "failed to get finality checkpoints")
+		ch <- prometheus.NewInvalidMetric(collector.finalizedDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.justifiedDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.distanceDesc, wErr)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(collector.finalizedDesc, prometheus.GaugeValue, float64(checkpoints.Finalized.Epoch))
+	ch <- prometheus.MustNewConstMetric(collector.justifiedDesc, prometheus.GaugeValue, float64(checkpoints.CurrentJustified.Epoch))
+
+	head, err := collector.client.BlockHeader(ctx, "head")
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.distanceDesc, errors.Wrap(err, "failed to get head block"))
+		return
+	}
+	slotsPerEpoch, err := collector.client.SlotsPerEpoch(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.distanceDesc, err)
+		return
+	}
+
+	distance := float64(head.Header.Message.Slot/slotsPerEpoch) - float64(checkpoints.Finalized.Epoch)
+	ch <- prometheus.MustNewConstMetric(collector.distanceDesc, prometheus.GaugeValue, distance)
+}
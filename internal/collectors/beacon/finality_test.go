@@ -0,0 +1,72 @@
+package beacon
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+)
+
+func TestBeaconFinalityCollect(t *testing.T) {
+	server := newMockBeaconServer(t, map[string]string{
+		"/eth/v1/beacon/headers/head": mockHeadHeader,
+		"/eth/v1/beacon/states/head/finality_checkpoints": `{"data": {
+			"previous_justified": {"epoch": "197", "root": "0x1"},
+			"current_justified": {"epoch": "198", "root": "0x2"},
+			"finalized": {"epoch": "196", "root": "0x3"}
+		}}`,
+		"/eth/v1/config/spec": `{"data": {"SLOTS_PER_EPOCH": "32", "SECONDS_PER_SLOT": "12"}}`,
+	})
+	defer server.Close()
+
+	collector := NewBeaconFinality(beacon.NewClient(server.URL, nil), mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %v, want 3", got)
+	}
+
+	// finalized epoch, justified epoch and distance from the head epoch (6400 / 32 = 200)
+	for _, want := range []float64{196, 198, 4} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBeaconFinalityCollectSpecError(t *testing.T) {
+	server := newMockBeaconServer(t, map[string]string{
+		"/eth/v1/beacon/headers/head": mockHeadHeader,
+		"/eth/v1/beacon/states/head/finality_checkpoints": `{"data": {
+			"previous_justified": {"epoch": "197", "root": "0x1"},
+			"current_justified": {"epoch": "198", "root": "0x2"},
+			"finalized": {"epoch": "196", "root": "0x3"}
+		}}`,
+	})
+	defer server.Close()
+
+	collector := NewBeaconFinality(beacon.NewClient(server.URL, nil), mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %v, want 3", got)
+	}
+
+	<-ch
+	<-ch
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err == nil {
+		t.Fatalf("expected invalid metric, got %#v", metric)
+	}
+}
@@ -0,0 +1,44 @@
+package beacon
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+)
+
+type BeaconHeadSlot struct {
+	client *beacon.Client
+	desc   *prometheus.Desc
+}
+
+func NewBeaconHeadSlot(client *beacon.Client, blockchain string) *BeaconHeadSlot {
+	return &BeaconHeadSlot{
+		client: client,
+		desc: prometheus.NewDesc(
+			"beacon_head_slot",
+			"slot of the head block of the beacon chain",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *BeaconHeadSlot) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+}
+
+func (collector *BeaconHeadSlot) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *BeaconHeadSlot) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	result, err := collector.client.BlockHeader(ctx, "head")
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, float64(result.Header.Message.Slot))
+}
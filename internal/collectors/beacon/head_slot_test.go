@@ -0,0 +1,41 @@
+package beacon
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+)
+
+const mockHeadHeader = `{"data": {"root": "0xabc", "canonical": true, "header": {"message": {"slot": "6400", "proposer_index": "7"}}}}`
+
+func TestBeaconHeadSlotCollect(t *testing.T) {
+	server := newMockBeaconServer(t, map[string]string{
+		"/eth/v1/beacon/headers/head": mockHeadHeader,
+	})
+	defer server.Close()
+
+	collector := NewBeaconHeadSlot(beacon.NewClient(server.URL, nil), mockBlockchainName)
+	ch := make(chan prometheus.Metric, 1)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+
+	var metric dto.Metric
+	for result := range ch {
+		if err := result.Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := len(metric.Label); got != 1 {
+			t.Fatalf("expected 1 label, got %d", got)
+		}
+		if got := *metric.Gauge.Value; got != 6400 {
+			t.Fatalf("got %v, want 6400", got)
+		}
+	}
+}
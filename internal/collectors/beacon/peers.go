@@ -0,0 +1,49 @@
+package beacon
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+)
+
+const stateLabel = "state"
+
+type BeaconPeerCount struct {
+	client *beacon.Client
+	desc   *prometheus.Desc
+}
+
+func NewBeaconPeerCount(client *beacon.Client, blockchain string) *BeaconPeerCount {
+	return &BeaconPeerCount{
+		client: client,
+		desc: prometheus.NewDesc(
+			"beacon_peers",
+			"number of consensus-layer peers by connection state",
+			[]string{stateLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *BeaconPeerCount) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+}
+
+func (collector *BeaconPeerCount) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *BeaconPeerCount) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	result, err := collector.client.PeerCount(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, float64(result.Connected), "connected")
+	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, float64(result.Connecting), "connecting")
+	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, float64(result.Disconnecting), "disconnecting")
+	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, float64(result.Disconnected), "disconnected")
+}
@@ -0,0 +1,64 @@
+package beacon
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+)
+
+func TestBeaconPeerCountCollectAPIError(t *testing.T) {
+	server := newMockBeaconServer(t, map[string]string{})
+	defer server.Close()
+
+	collector := NewBeaconPeerCount(beacon.NewClient(server.URL, nil), mockBlockchainName)
+	ch := make(chan prometheus.Metric, 1)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+
+	var metric dto.Metric
+	err := (<-ch).Write(&metric)
+	apiErr, ok := err.(*beacon.APIError)
+	if !ok {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	if apiErr.StatusCode != 404 || apiErr.Message != "not found" {
+		t.Fatalf("unexpected API error %#v", apiErr)
+	}
+}
+
+func TestBeaconPeerCountCollect(t *testing.T) {
+	server := newMockBeaconServer(t, map[string]string{
+		"/eth/v1/node/peer_count": `{"data": {"disconnected": "4", "connecting": "2", "connected": "50", "disconnecting": "1"}}`,
+	})
+	defer server.Close()
+
+	collector := NewBeaconPeerCount(beacon.NewClient(server.URL, nil), mockBlockchainName)
+	ch := make(chan prometheus.Metric, 4)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 4 {
+		t.Fatalf("got %v, want 4", got)
+	}
+
+	for _, want := range []float64{50, 2, 1, 4} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := len(metric.Label); got != 2 {
+			t.Fatalf("expected 2 labels, got %d", got)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package beacon
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+)
+
+type BeaconSyncing struct {
+	client       *beacon.Client
+	syncingDesc  *prometheus.Desc
+	distanceDesc *prometheus.Desc
+}
+
+func NewBeaconSyncing(client *beacon.Client, blockchain string) *BeaconSyncing {
+	return &BeaconSyncing{
+		client: client,
+		syncingDesc: prometheus.NewDesc(
+			"beacon_syncing",
+			"whether the beacon node is syncing",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		distanceDesc: prometheus.NewDesc(
+			"beacon_sync_distance",
+			"number of slots the beacon node is behind the head of the chain",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *BeaconSyncing) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.syncingDesc
+	ch <- collector.distanceDesc
+}
+
+func (collector *BeaconSyncing) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *BeaconSyncing) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	result, err := collector.client.Syncing(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.syncingDesc, err)
+		ch <- prometheus.NewInvalidMetric(collector.distanceDesc, err)
+		return
+	}
+
+	var syncing float64
+	if result.IsSyncing {
+		syncing = 1
+	}
+	ch <- prometheus.MustNewConstMetric(collector.syncingDesc, prometheus.GaugeValue, syncing)
+	ch <- prometheus.MustNewConstMetric(collector.distanceDesc, prometheus.GaugeValue, float64(result.SyncDistance))
+}
@@ -0,0 +1,60 @@
+package beacon
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+)
+
+func TestBeaconSyncingCollectError(t *testing.T) {
+	collector := NewBeaconSyncing(beacon.NewClient("http://localhost", nil), mockBlockchainName)
+	ch := make(chan prometheus.Metric, 2)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+
+	var metric dto.Metric
+	for result := range ch {
+		err := result.Write(&metric)
+		if err == nil {
+			t.Fatalf("expected invalid metric, got %#v", metric)
+		}
+		if _, ok := err.(*url.Error); !ok {
+			t.Fatalf("unexpected error %#v", err)
+		}
+	}
+}
+
+func TestBeaconSyncingCollect(t *testing.T) {
+	server := newMockBeaconServer(t, map[string]string{
+		"/eth/v1/node/syncing": `{"data": {"head_slot": "4000", "sync_distance": "12", "is_syncing": true, "is_optimistic": false}}`,
+	})
+	defer server.Close()
+
+	collector := NewBeaconSyncing(beacon.NewClient(server.URL, nil), mockBlockchainName)
+	ch := make(chan prometheus.Metric, 2)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+
+	for _, want := range []float64{1, 12} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
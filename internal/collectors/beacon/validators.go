@@ -0,0 +1,288 @@
+package beacon
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+const (
+	indexLabel  = "index"
+	statusLabel = "status"
+)
+
+// validatorStatuses are the validator states defined by the Beacon API.
+var validatorStatuses = []string{
+	"pending_initialized",
+	"pending_queued",
+	"active_ongoing",
+	"active_exiting",
+	"active_slashed",
+	"exited_unslashed",
+	"exited_slashed",
+	"withdrawal_possible",
+	"withdrawal_done",
+}
+
+var weiPerGwei = big.NewInt(1e9)
+
+type validatorCounters struct {
+	missedAttestations uint64
+	missedProposals    uint64
+}
+
+// BeaconValidators exports the balance and status of the configured validators, and counts the attestations and
+// block proposals they missed. Each finished epoch is checked once, on the first scrape after it ends. Nodes only
+// answer liveness for the current and previous epochs, so only the previous one can be checked, and older epochs
+// not checked yet are skipped.
+type BeaconValidators struct {
+	client *beacon.Client
+	ids    []string
+	// names maps validator indices and lowercase public keys to their configured names.
+	names                  map[string]string
+	unit                   units.Unit
+	balanceDesc            *prometheus.Desc
+	effectiveBalanceDesc   *prometheus.Desc
+	statusDesc             *prometheus.Desc
+	missedAttestationsDesc *prometheus.Desc
+	missedProposalsDesc    *prometheus.Desc
+	skippedEpochsDesc      *prometheus.Desc
+
+	mutex              sync.Mutex
+	epochProcessed     bool
+	lastProcessedEpoch uint64
+	skippedEpochs      uint64
+	counters           map[uint64]*validatorCounters
+}
+
+func NewBeaconValidators(client *beacon.Client, validators []config.ValidatorTarget, unit units.Unit, blockchain string) *BeaconValidators {
+	collector := &BeaconValidators{
+		client:   client,
+		unit:     unit,
+		counters: map[uint64]*validatorCounters{},
+		balanceDesc: prometheus.NewDesc(
+			"beacon_validator_balance",
+			"validator balance in "+string(unit),
+			[]string{constants.NameLabel, indexLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		effectiveBalanceDesc: prometheus.NewDesc(
+			"beacon_validator_effective_balance",
+			"validator effective balance in "+string(unit),
+			[]string{constants.NameLabel, indexLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		statusDesc: prometheus.NewDesc(
+			"beacon_validator_status",
+			"validator status, 1 for the current status and 0 for the rest",
+			[]string{constants.NameLabel, indexLabel, statusLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		missedAttestationsDesc: prometheus.NewDesc(
+			"beacon_validator_missed_attestations_total",
+			"number of epochs in which an active validator was not seen attesting",
+			[]string{constants.NameLabel, indexLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		missedProposalsDesc: prometheus.NewDesc(
+			"beacon_validator_missed_proposals_total",
+			"number of block proposals missed by the validator",
+			[]string{constants.NameLabel, indexLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		skippedEpochsDesc: prometheus.NewDesc(
+			"beacon_validator_skipped_epochs_total",
+			"number of finished epochs whose validator duties were never checked",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+	collector.ids, collector.names = validatorIDs(validators)
+	return collector
+}
+
+func validatorIDs(validators []config.ValidatorTarget) ([]string, map[string]string) {
+	var ids []string
+	names := map[string]string{}
+	for _, v := range validators {
+		ids = append(ids, v.ID())
+		names[strings.ToLower(v.ID())] = v.Name
+	}
+	return ids, names
+}
+
+// SetValidators replaces the monitored validators. The missed duty counters of validators that were already
+// monitored are kept.
+func (collector *BeaconValidators) SetValidators(validators []config.ValidatorTarget) {
+	ids, names := validatorIDs(validators)
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.ids, collector.names = ids, names
+}
+
+func (collector *BeaconValidators) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.balanceDesc
+	ch <- collector.effectiveBalanceDesc
+	ch <- collector.statusDesc
+	ch <- collector.missedAttestationsDesc
+	ch <- collector.missedProposalsDesc
+	ch <- collector.skippedEpochsDesc
+}
+
+func (collector *BeaconValidators) nameOf(v *beacon.Validator) string {
+	if name, ok := collector.names[strconv.FormatUint(v.Index, 10)]; ok {
+		return name
+	}
+	return collector.names[strings.ToLower(v.Validator.Pubkey)]
+}
+
+func (collector *BeaconValidators) fromGwei(gwei uint64) float64 {
+	return collector.unit.FromWei(new(big.Int).Mul(new(big.Int).SetUint64(gwei), weiPerGwei))
+}
+
+func (collector *BeaconValidators) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *BeaconValidators) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	validators, err := collector.client.Validators(ctx, "head", collector.ids)
+	if err != nil {
+		wErr := errors.Wrap(err, "failed to get validators")
+		ch <- prometheus.NewInvalidMetric(collector.balanceDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.effectiveBalanceDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.statusDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.missedAttestationsDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.missedProposalsDesc, wErr)
+		return
+	}
+
+	for i := range validators {
+		v := &validators[i]
+		name, index := collector.nameOf(v), strconv.FormatUint(v.Index, 10)
+		ch <- prometheus.MustNewConstMetric(collector.balanceDesc, prometheus.GaugeValue, collector.fromGwei(v.Balance), name, index)
+		ch <- prometheus.MustNewConstMetric(collector.effectiveBalanceDesc, prometheus.GaugeValue, collector.fromGwei(v.Validator.EffectiveBalance), name, index)
+		for _, status := range validatorStatuses {
+			var value float64
+			if v.Status == status {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(collector.statusDesc, prometheus.GaugeValue, value, name, index, status)
+		}
+	}
+
+	err = collector.processEpochs(ctx, validators)
+	ch <- prometheus.MustNewConstMetric(collector.skippedEpochsDesc, prometheus.CounterValue, float64(collector.skippedEpochs))
+	if err != nil {
+		wErr := errors.Wrap(err, "failed to check validator duties")
+		ch <- prometheus.NewInvalidMetric(collector.missedAttestationsDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.missedProposalsDesc, wErr)
+		return
+	}
+
+	for i := range validators {
+		v := &validators[i]
+		name, index := collector.nameOf(v), strconv.FormatUint(v.Index, 10)
+		counters := collector.counters[v.Index]
+		if counters == nil {
+			counters = &validatorCounters{}
+		}
+		ch <- prometheus.MustNewConstMetric(collector.missedAttestationsDesc, prometheus.CounterValue, float64(counters.missedAttestations), name, index)
+		ch <- prometheus.MustNewConstMetric(collector.missedProposalsDesc, prometheus.CounterValue, float64(counters.missedProposals), name, index)
+	}
+}
+
+// processEpochs checks the attestations and proposals of the active validators in the previous epoch, if it hasn't
+// been checked yet, counting the older epochs that were never checked as skipped.
+func (collector *BeaconValidators) processEpochs(ctx context.Context, validators []beacon.Validator) error {
+	head, err := collector.client.BlockHeader(ctx, "head")
+	if err != nil {
+		return errors.Wrap(err, "failed to get head block")
+	}
+	slotsPerEpoch, err := collector.client.SlotsPerEpoch(ctx)
+	if err != nil {
+		return err
+	}
+
+	headEpoch := head.Header.Message.Slot / slotsPerEpoch
+	if headEpoch == 0 {
+		return nil
+	}
+	epoch := headEpoch - 1
+	if collector.epochProcessed {
+		if epoch <= collector.lastProcessedEpoch {
+			return nil
+		}
+		// the node no longer answers for the epochs between
+		collector.skippedEpochs += epoch - collector.lastProcessedEpoch - 1
+		collector.lastProcessedEpoch = epoch - 1
+	}
+
+	var active []uint64
+	isActive := map[uint64]bool{}
+	for _, v := range validators {
+		if strings.HasPrefix(v.Status, "active_") {
+			active = append(active, v.Index)
+			isActive[v.Index] = true
+		}
+	}
+	return collector.processEpoch(ctx, epoch, active, isActive)
+}
+
+// processEpoch checks the attestations and proposals of the active validators in epoch. Counters are only updated
+// once every check succeeded.
+func (collector *BeaconValidators) processEpoch(ctx context.Context, epoch uint64, active []uint64, isActive map[uint64]bool) error {
+	missedAttestations := map[uint64]uint64{}
+	missedProposals := map[uint64]uint64{}
+	if len(active) > 0 {
+		liveness, err := collector.client.Liveness(ctx, epoch, active)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get liveness for epoch %d", epoch)
+		}
+		for _, l := range liveness {
+			if !l.IsLive {
+				missedAttestations[l.Index]++
+			}
+		}
+
+		duties, err := collector.client.ProposerDuties(ctx, epoch)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get proposer duties for epoch %d", epoch)
+		}
+		for _, duty := range duties {
+			if !isActive[duty.ValidatorIndex] {
+				continue
+			}
+			_, err := collector.client.BlockHeader(ctx, strconv.FormatUint(duty.Slot, 10))
+			if beacon.IsNotFound(err) {
+				missedProposals[duty.ValidatorIndex]++
+			} else if err != nil {
+				return errors.Wrapf(err, "failed to get block at slot %d", duty.Slot)
+			}
+		}
+	}
+
+	for _, index := range active {
+		counters := collector.counters[index]
+		if counters == nil {
+			counters = &validatorCounters{}
+			collector.counters[index] = counters
+		}
+		counters.missedAttestations += missedAttestations[index]
+		counters.missedProposals += missedProposals[index]
+	}
+	collector.epochProcessed = true
+	collector.lastProcessedEpoch = epoch
+	return nil
+}
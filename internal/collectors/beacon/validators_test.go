@@ -0,0 +1,193 @@
+package beacon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/beacon"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+const mockValidatorPubkey = "0xa1d1ad0714035353258038e964ae9675dc0252ee22cea896825c01458e1807bfad2f9969338798548d9858a571f7425c"
+
+func newMockValidatorsServer(t *testing.T) (*beacon.Client, func()) {
+	server := newMockBeaconServer(t, map[string]string{
+		"/eth/v1/config/spec":         `{"data": {"SLOTS_PER_EPOCH": "32"}}`,
+		"/eth/v1/beacon/headers/head": mockHeadHeader,
+		"/eth/v1/beacon/headers/6371": `{"data": {"root": "0xdef", "canonical": true, "header": {"message": {"slot": "6371", "proposer_index": "5678"}}}}`,
+		"/eth/v1/beacon/states/head/validators": `{"data": [
+			{"index": "1234", "balance": "32001000000", "status": "active_ongoing", "validator": {"pubkey": "0x01", "effective_balance": "32000000000", "slashed": false}},
+			{"index": "5678", "balance": "31000000000", "status": "active_ongoing", "validator": {"pubkey": "` + mockValidatorPubkey + `", "effective_balance": "31000000000", "slashed": false}}
+		]}`,
+		"/eth/v1/validator/liveness/199": `{"data": [{"index": "1234", "is_live": false}, {"index": "5678", "is_live": true}]}`,
+		"/eth/v1/validator/duties/proposer/199": `{"data": [
+			{"pubkey": "0x01", "validator_index": "1234", "slot": "6370"},
+			{"pubkey": "` + mockValidatorPubkey + `", "validator_index": "5678", "slot": "6371"},
+			{"pubkey": "0x02", "validator_index": "42", "slot": "6372"}
+		]}`,
+	})
+	return beacon.NewClient(server.URL, nil), server.Close
+}
+
+func collectValidators(t *testing.T, collector *BeaconValidators) map[string]map[string]float64 {
+	ch := make(chan prometheus.Metric, 27)
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 27 {
+		t.Fatalf("got %v, want 27", got)
+	}
+
+	// values by metric name and validator name, skipping statuses other than the current one
+	values := map[string]map[string]float64{}
+	for result := range ch {
+		var metric dto.Metric
+		if err := result.Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		labels := map[string]string{}
+		for _, l := range metric.Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+		name := result.Desc().String()
+		value := metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+		if _, ok := labels[statusLabel]; ok && value == 0 {
+			continue
+		}
+		if values[name] == nil {
+			values[name] = map[string]float64{}
+		}
+		values[name][labels[constants.NameLabel]] = value
+	}
+	return values
+}
+
+func TestBeaconValidatorsCollect(t *testing.T) {
+	client, closeServer := newMockValidatorsServer(t)
+	defer closeServer()
+
+	one := uint64(1234)
+	collector := NewBeaconValidators(client, []config.ValidatorTarget{
+		{Name: "validator 1", Index: &one},
+		{Name: "validator 2", Pubkey: mockValidatorPubkey},
+	}, units.Ether, mockBlockchainName)
+
+	for i := 0; i < 2; i++ {
+		// the second scrape happens in the same epoch, and must not count misses again
+		values := collectValidators(t, collector)
+
+		expected := map[*prometheus.Desc]map[string]float64{
+			collector.balanceDesc:            {"validator 1": 32.001, "validator 2": 31},
+			collector.effectiveBalanceDesc:   {"validator 1": 32, "validator 2": 31},
+			collector.statusDesc:             {"validator 1": 1, "validator 2": 1},
+			collector.missedAttestationsDesc: {"validator 1": 1, "validator 2": 0},
+			collector.missedProposalsDesc:    {"validator 1": 1, "validator 2": 0},
+		}
+		for desc, want := range expected {
+			for validator, v := range want {
+				if got := values[desc.String()][validator]; got != v {
+					t.Fatalf("%s for %s: got %v, want %v", desc, validator, got, v)
+				}
+			}
+		}
+	}
+}
+
+func TestBeaconValidatorsSetValidators(t *testing.T) {
+	client, closeServer := newMockValidatorsServer(t)
+	defer closeServer()
+
+	one := uint64(1234)
+	collector := NewBeaconValidators(client, []config.ValidatorTarget{
+		{Name: "validator 1", Index: &one},
+		{Name: "validator 2", Pubkey: mockValidatorPubkey},
+	}, units.Ether, mockBlockchainName)
+	collectValidators(t, collector)
+
+	// renaming a validator keeps its counters
+	collector.SetValidators([]config.ValidatorTarget{
+		{Name: "renamed", Index: &one},
+		{Name: "validator 2", Pubkey: mockValidatorPubkey},
+	})
+	values := collectValidators(t, collector)
+
+	if got := values[collector.missedAttestationsDesc.String()]["renamed"]; got != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+	if _, ok := values[collector.balanceDesc.String()]["validator 1"]; ok {
+		t.Fatalf("expected no metrics under the old validator name")
+	}
+}
+
+func TestBeaconValidatorsCollectMissedEpochs(t *testing.T) {
+	var mutex sync.Mutex
+	head := mockHeadHeader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var body string
+		switch {
+		case r.URL.Path == "/eth/v1/config/spec":
+			body = `{"data": {"SLOTS_PER_EPOCH": "32"}}`
+		case r.URL.Path == "/eth/v1/beacon/headers/head":
+			body = head
+		case r.URL.Path == "/eth/v1/beacon/states/head/validators":
+			body = `{"data": [{"index": "1234", "balance": "32000000000", "status": "active_ongoing", "validator": {"pubkey": "0x01", "effective_balance": "32000000000", "slashed": false}}]}`
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/validator/liveness/"):
+			body = `{"data": [{"index": "1234", "is_live": false}]}`
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/validator/duties/proposer/"):
+			body = `{"data": []}`
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer server.Close()
+
+	one := uint64(1234)
+	collector := NewBeaconValidators(beacon.NewClient(server.URL, nil), []config.ValidatorTarget{
+		{Name: "validator 1", Index: &one},
+	}, units.Ether, mockBlockchainName)
+	collect := func() map[string]float64 {
+		ch := make(chan prometheus.Metric, 15)
+		collector.Collect(ch)
+		close(ch)
+		values := map[string]float64{}
+		for result := range ch {
+			var metric dto.Metric
+			if err := result.Write(&metric); err != nil {
+				t.Fatalf("expected metric, got %#v", err)
+			}
+			values[result.Desc().String()] += metric.GetCounter().GetValue()
+		}
+		return values
+	}
+
+	// the first scrape checks the previous epoch, 199
+	collect()
+
+	// 11 epochs later, only the previous epoch is checked and the 10 older ones are skipped
+	mutex.Lock()
+	head = `{"data": {"root": "0xabc", "canonical": true, "header": {"message": {"slot": "6752", "proposer_index": "7"}}}}`
+	mutex.Unlock()
+	values := collect()
+
+	if got := values[collector.missedAttestationsDesc.String()]; got != 2 {
+		t.Fatalf("got %v missed attestations, want 2", got)
+	}
+	if got := values[collector.skippedEpochsDesc.String()]; got != 10 {
+		t.Fatalf("got %v skipped epochs, want 10", got)
+	}
+	if collector.lastProcessedEpoch != 210 {
+		t.Fatalf("got last processed epoch %d, want 210", collector.lastProcessedEpoch)
+	}
+}
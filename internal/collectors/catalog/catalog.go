@@ -0,0 +1,81 @@
+// Package catalog lists the collectors of the exporter along with their options, as toggled and set through the
+// collectors section of the config and the -collector.<name> flags.
+package catalog
+
+// Kinds of option values.
+const (
+	// UnitOption is one of wei, gwei or ether.
+	UnitOption = "unit"
+	// BoolOption is true or false.
+	BoolOption = "bool"
+)
+
+type Option struct {
+	Name string
+	Kind string
+	Help string
+}
+
+type Collector struct {
+	Name    string
+	Help    string
+	Options []Option
+}
+
+// Option returns the option of c with the given name.
+func (c Collector) Option(name string) (Option, bool) {
+	for _, option := range c.Options {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+var (
+	balanceUnit       = Option{"unit", UnitOption, "unit of balances, instead of general.balance_unit"}
+	gasPriceUnit      = Option{"unit", UnitOption, "unit of gas prices, instead of general.gas_price_unit"}
+	feeUnit           = Option{"unit", UnitOption, "unit of fees, instead of general.balance_unit"}
+	exportExactValues = Option{"export_exact_values", BoolOption, "export exact wei amounts, instead of general.export_exact_values"}
+)
+
+// Collectors are all the collectors of the exporter, in registration order.
+var Collectors = []Collector{
+	{Name: "net_peers", Help: "peers connected to the node"},
+	{Name: "client_info", Help: "client version and chain identity"},
+	{Name: "block_number", Help: "latest, safe and finalized block numbers"},
+	{Name: "block_timestamp", Help: "timestamp of the latest block"},
+	{Name: "gas_price", Help: "current gas price", Options: []Option{gasPriceUnit, exportExactValues}},
+	{Name: "earliest_block_transactions", Help: "transactions in the earliest block"},
+	{Name: "latest_block_transactions", Help: "transactions in the latest block"},
+	{Name: "pending_block_transactions", Help: "transactions in the pending block"},
+	{Name: "hashrate", Help: "hashes per second the node is mining with"},
+	{Name: "syncing", Help: "sync status and progress"},
+	{Name: "erc20_transfer_events", Help: "transfer events of the ERC-20 targets"},
+	{Name: "balance", Help: "balance of the wallet targets", Options: []Option{balanceUnit, exportExactValues}},
+	{Name: "wallet_balance_threshold", Help: "balance thresholds of the wallet targets", Options: []Option{balanceUnit}},
+	{Name: "erc20_approval_events", Help: "approval events of the ERC-20 targets"},
+	{Name: "erc20_tokens", Help: "total supply of the ERC-20 targets and balances of the wallet targets in them"},
+	{Name: "balance_snapshot", Help: "wallet balance snapshots, requires balance_history", Options: []Option{balanceUnit}},
+	{Name: "admin_peers", Help: "peers of the node, requires admin_api"},
+	{Name: "admin_node_info", Help: "node identity, requires admin_api"},
+	{Name: "beacon_syncing", Help: "sync status of the consensus client, requires beacon_provider_url"},
+	{Name: "beacon_peers", Help: "peers of the consensus client, requires beacon_provider_url"},
+	{Name: "beacon_head_slot", Help: "head slot of the consensus client, requires beacon_provider_url"},
+	{Name: "beacon_finality", Help: "justified and finalized checkpoints, requires beacon_provider_url"},
+	{Name: "beacon_validators", Help: "status and balance of the validator targets, requires beacon_provider_url", Options: []Option{balanceUnit}},
+	{Name: "optimism_l1_fees", Help: "L1 fees of the latest block, requires the optimism l2_type", Options: []Option{feeUnit}},
+	{Name: "optimism_sync_status", Help: "sync status of the rollup node, requires l2_rollup_url"},
+	{Name: "arbitrum_l1_fees", Help: "L1 gas of the latest block, requires the arbitrum l2_type"},
+	{Name: "arbitrum_batch_posting", Help: "batch posting lag, requires the arbitrum l2_type"},
+}
+
+// Lookup returns the collector with the given name.
+func Lookup(name string) (Collector, bool) {
+	for _, collector := range Collectors {
+		if collector.Name == name {
+			return collector, true
+		}
+	}
+	return Collector{}, false
+}
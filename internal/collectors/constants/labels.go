@@ -3,4 +3,5 @@ package constants
 const (
 	BlockchainNameLabel string = "blockchain"
 	NameLabel                  = "name"
+	WeiLabel                   = "wei"
 )
@@ -0,0 +1,92 @@
+package erc20
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/multicall"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// tokenABI holds the ERC-20 view functions read through Multicall3.
+const tokenABI = `[{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+var parsedTokenABI = mustParseABI(tokenABI)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// tokenCall returns the call of a view function of a token.
+func tokenCall(contract common.Address, method string, args ...interface{}) multicall.Call {
+	data, err := parsedTokenABI.Pack(method, args...)
+	if err != nil {
+		// the arguments always match tokenABI
+		panic(err)
+	}
+	return multicall.Call{Target: contract, Data: data}
+}
+
+// unpackToken unpacks the single output of a view function of a token.
+func unpackToken(method string, result multicall.Result) (interface{}, error) {
+	if !result.Success {
+		return nil, errors.New("call reverted")
+	}
+	out, err := parsedTokenABI.Unpack(method, result.ReturnData)
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func unpackAmount(method string, result multicall.Result) (*big.Int, error) {
+	out, err := unpackToken(method, result)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*big.Int), nil
+}
+
+// getContractInfos reads the symbol and decimals of the contracts, aggregating the calls of all of them.
+func getContractInfos(ctx context.Context, aggregator *multicall.Aggregator, contracts []config.ERC20Target) ([]*contractInfo, error) {
+	if len(contracts) == 0 {
+		return nil, nil
+	}
+	calls := make([]multicall.Call, 0, 2*len(contracts))
+	for _, contract := range contracts {
+		address := common.HexToAddress(contract.ContractAddr)
+		calls = append(calls, tokenCall(address, "symbol"), tokenCall(address, "decimals"))
+	}
+	_, results, err := aggregator.Aggregate(ctx, calls)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get contract info")
+	}
+
+	infos := make([]*contractInfo, len(contracts))
+	for i, contract := range contracts {
+		address := common.HexToAddress(contract.ContractAddr)
+		symbol, err := unpackToken("symbol", results[2*i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get symbol for %s", address.Hex())
+		}
+		decimals, err := unpackToken("decimals", results[2*i+1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get decimals for %s", address.Hex())
+		}
+		infos[i] = &contractInfo{
+			Address:  address.Hex(),
+			Symbol:   symbol.(string),
+			Decimals: decimals.(uint8),
+			Name:     contract.Name,
+		}
+	}
+	return infos, nil
+}
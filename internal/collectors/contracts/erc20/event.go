@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/multicall"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
 )
 
@@ -30,51 +31,185 @@ type contractInfo struct {
 	Name     string
 }
 
+// contractState holds the cursor and the running totals of the events of a contract.
+type contractState struct {
+	info     *contractInfo
+	filterer *erc20.ContractFilterer
+	// fromBlock is the first block whose events were not counted yet.
+	fromBlock uint64
+	count     uint64
+	sum       float64
+}
+
+// eventCounter counts the events of a contract from its cursor up to the end block, and sums their token amounts.
+type eventCounter func(ctx context.Context, state *contractState, end uint64) (count uint64, sum float64, err error)
+
 type Event struct {
-	contractClients  map[*contractInfo]*erc20.ContractFilterer
-	desc             *prometheus.Desc
-	collectMutex     sync.Mutex
-	lastQueriedBlock uint64
-	bnGetter         BlockNumberGetter
+	client     ContractClient
+	aggregator *multicall.Aggregator
+	// contracts maps checksummed contract addresses to their state.
+	contracts    map[string]*contractState
+	desc         *prometheus.Desc
+	collectMutex sync.Mutex
+	bnGetter     BlockNumberGetter
+
+	runningMutex sync.Mutex
+	// running is only set while a collect is in progress.
+	running *eventCollect
 }
 
-func getContractInfo(contractAddr common.Address, contractClient bind.ContractCaller, name string) (*contractInfo, error) {
-	contractCaller, err := erc20.NewContractCaller(contractAddr, contractClient)
+func newContractStates(client ContractClient, aggregator *multicall.Aggregator, contractAddresses []config.ERC20Target, fromBlock uint64) (map[string]*contractState, error) {
+	infos, err := getContractInfos(context.Background(), aggregator, contractAddresses)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get contract info for %s", contractAddr.Hex())
+		return nil, err
 	}
-	symbol, err := contractCaller.Symbol(nil)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get symbol for %s", contractAddr.Hex())
+
+	states := map[string]*contractState{}
+	for _, info := range infos {
+		filterer, err := erc20.NewContractFilterer(common.HexToAddress(info.Address), client)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create ERC20 event collector")
+		}
+
+		log.Printf("Got info for %s, symbol %s\n", info.Address, info.Symbol)
+		states[info.Address] = &contractState{
+			info:      info,
+			filterer:  filterer,
+			fromBlock: fromBlock,
+		}
+	}
+
+	return states, nil
+}
+
+// eventCollect is a collect in progress, whose metrics are sent to the scrapes that joined it once done.
+type eventCollect struct {
+	done    chan struct{}
+	metrics []prometheus.Metric
+}
+
+// collect counts the events of every contract since the last scrape, and publishes the running totals as histograms.
+// Concurrent scrapes join the collect in progress instead of counting the same events once it is done.
+func (e *Event) collect(ctx context.Context, ch chan<- prometheus.Metric, countEvents eventCounter) {
+	e.runningMutex.Lock()
+	run := e.running
+	if run == nil {
+		run = &eventCollect{done: make(chan struct{})}
+		e.running = run
+		e.runningMutex.Unlock()
+
+		run.metrics = e.collectMetrics(ctx, countEvents)
+		e.runningMutex.Lock()
+		e.running = nil
+		e.runningMutex.Unlock()
+		close(run.done)
+	} else {
+		e.runningMutex.Unlock()
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			ch <- prometheus.NewInvalidMetric(e.desc, ctx.Err())
+			return
+		}
+	}
+
+	for _, metric := range run.metrics {
+		ch <- metric
 	}
-	decimals, err := contractCaller.Decimals(nil)
+}
+
+// collectMetrics counts the events of every contract since the last scrape, and returns the running totals as
+// histograms. A contract failing to be queried keeps its cursor, so its events are counted on the next scrape.
+func (e *Event) collectMetrics(ctx context.Context, countEvents eventCounter) []prometheus.Metric {
+	e.collectMutex.Lock()
+	defer e.collectMutex.Unlock()
+
+	currentBlockNumber, err := e.bnGetter.BlockNumber(ctx)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get decimals for %s", contractAddr.Hex())
+		wErr := errors.Wrap(err, "failed to get current block number")
+		return []prometheus.Metric{prometheus.NewInvalidMetric(e.desc, wErr)}
 	}
-	return &contractInfo{
-		Address:  contractAddr.Hex(),
-		Symbol:   symbol,
-		Decimals: decimals,
-		Name:     name,
-	}, nil
+
+	// every contract sends a single metric
+	ch := make(chan prometheus.Metric, len(e.contracts))
+	wg := sync.WaitGroup{}
+	for _, state := range e.contracts {
+		wg.Add(1)
+
+		go func(state *contractState) {
+			defer wg.Done()
+			// the indexed head can be behind the cursor, e.g. when indexing up to the finalized block
+			if currentBlockNumber >= state.fromBlock {
+				count, sum, err := countEvents(ctx, state, currentBlockNumber)
+				if err != nil {
+					ch <- prometheus.NewInvalidMetric(e.desc, err)
+					return
+				}
+				state.count += count
+				state.sum += sum
+				state.fromBlock = currentBlockNumber + 1
+			}
+			ch <- prometheus.MustNewConstHistogram(e.desc, state.count, state.sum, nil, state.info.Address, state.info.Symbol, state.info.Name)
+		}(state)
+	}
+
+	wg.Wait()
+	close(ch)
+	metrics := make([]prometheus.Metric, 0, len(ch))
+	for metric := range ch {
+		metrics = append(metrics, metric)
+	}
+	return metrics
 }
 
-func getContractClients(client ContractClient, contractAddresses []config.ERC20Target) (map[*contractInfo]*erc20.ContractFilterer, error) {
-	clients := map[*contractInfo]*erc20.ContractFilterer{}
-	for _, contractAddress := range contractAddresses {
-		address := common.HexToAddress(contractAddress.ContractAddr)
-		filterer, err := erc20.NewContractFilterer(address, client)
+// PrepareTargets reads the info of the contracts that aren't monitored yet, and returns a func replacing the monitored
+// contracts, which can't fail, so callers can apply targets to several collectors at once. Contracts that were already
+// monitored keep their cursor and totals, while new ones are indexed from the current block. The returned func must be
+// called before targets are prepared again.
+func (e *Event) PrepareTargets(contractAddresses []config.ERC20Target) (func(), error) {
+	e.collectMutex.Lock()
+	known := make(map[string]bool, len(e.contracts))
+	for address := range e.contracts {
+		known[address] = true
+	}
+	e.collectMutex.Unlock()
+
+	var added []config.ERC20Target
+	for _, target := range contractAddresses {
+		if !known[common.HexToAddress(target.ContractAddr).Hex()] {
+			added = append(added, target)
+		}
+	}
+
+	var addedStates map[string]*contractState
+	if len(added) > 0 {
+		currentBlockNumber, err := e.bnGetter.BlockNumber(context.Background())
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create ERC20 event collector")
+			return nil, errors.Wrap(err, "failed to get current block number")
 		}
-		info, err := getContractInfo(address, client, contractAddress.Name)
+		addedStates, err = newContractStates(e.client, e.aggregator, added, currentBlockNumber)
 		if err != nil {
 			return nil, err
 		}
-
-		log.Printf("Got info for %s, symbol %s\n", info.Address, info.Symbol)
-		clients[info] = filterer
 	}
 
-	return clients, nil
+	return func() {
+		e.collectMutex.Lock()
+		defer e.collectMutex.Unlock()
+
+		states := map[string]*contractState{}
+		for _, target := range contractAddresses {
+			address := common.HexToAddress(target.ContractAddr).Hex()
+			state, ok := e.contracts[address]
+			if !ok {
+				state = addedStates[address]
+			} else if state.info.Name != target.Name {
+				info := *state.info
+				info.Name = target.Name
+				state.info = &info
+			}
+			states[address] = state
+		}
+		e.contracts = states
+	}, nil
 }
@@ -0,0 +1,49 @@
+package erc20
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/eth"
+)
+
+// TaggedBlockNumber is a BlockNumberGetter for the block with a tag such as safe or finalized, so events are only
+// indexed once their block reaches that tag.
+type TaggedBlockNumber struct {
+	rpc eth.Caller
+	tag string
+}
+
+func NewTaggedBlockNumber(rpc eth.Caller, tag string) *TaggedBlockNumber {
+	return &TaggedBlockNumber{rpc: rpc, tag: tag}
+}
+
+func (getter *TaggedBlockNumber) BlockNumber(ctx context.Context) (uint64, error) {
+	number, err := eth.BlockNumberByTag(ctx, getter.rpc, getter.tag)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get %s block number", getter.tag)
+	}
+	return number, nil
+}
+
+// ConfirmedBlockNumber is a BlockNumberGetter for the block a number of confirmations behind the head, so events are
+// only indexed once their block is that deep.
+type ConfirmedBlockNumber struct {
+	head          BlockNumberGetter
+	confirmations uint64
+}
+
+func NewConfirmedBlockNumber(head BlockNumberGetter, confirmations uint64) *ConfirmedBlockNumber {
+	return &ConfirmedBlockNumber{head: head, confirmations: confirmations}
+}
+
+func (getter *ConfirmedBlockNumber) BlockNumber(ctx context.Context) (uint64, error) {
+	number, err := getter.head.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if number < getter.confirmations {
+		return 0, nil
+	}
+	return number - getter.confirmations, nil
+}
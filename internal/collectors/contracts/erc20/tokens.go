@@ -0,0 +1,170 @@
+package erc20
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/multicall"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+const walletLabel = "wallet"
+
+// Tokens collects the total supply of the contracts, and the balance of the wallets in each of them. All the reads of
+// a scrape are aggregated through Multicall3 at the same block, so the values are consistent with each other.
+type Tokens struct {
+	aggregator *multicall.Aggregator
+	mutex      sync.RWMutex
+	contracts  []*contractInfo
+	wallets    []config.WalletTarget
+
+	supplyDesc  *prometheus.Desc
+	balanceDesc *prometheus.Desc
+	blockDesc   *prometheus.Desc
+}
+
+func NewERC20Tokens(aggregator *multicall.Aggregator, contractAddresses []config.ERC20Target, wallets []config.WalletTarget, blockchain string) (*Tokens, error) {
+	contracts, err := getContractInfos(context.Background(), aggregator, contractAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		constants.BlockchainNameLabel: blockchain,
+	}
+	return &Tokens{
+		aggregator: aggregator,
+		contracts:  contracts,
+		wallets:    wallets,
+		supplyDesc: prometheus.NewDesc(
+			"erc20_total_supply",
+			"ERC20 total supply, in tokens",
+			[]string{"contract", "symbol", constants.NameLabel},
+			labels,
+		),
+		balanceDesc: prometheus.NewDesc(
+			"erc20_balance",
+			"ERC20 balance of a wallet, in tokens",
+			[]string{"contract", "symbol", constants.NameLabel, walletLabel},
+			labels,
+		),
+		blockDesc: prometheus.NewDesc(
+			"erc20_tokens_block",
+			"block number the ERC20 supplies and balances were read at",
+			nil,
+			labels,
+		),
+	}, nil
+}
+
+func (col *Tokens) Describe(ch chan<- *prometheus.Desc) {
+	ch <- col.supplyDesc
+	ch <- col.balanceDesc
+	ch <- col.blockDesc
+}
+
+// PrepareTargets reads the info of new contracts, and returns a func replacing the monitored contracts and wallets,
+// which can't fail.
+func (col *Tokens) PrepareTargets(contractAddresses []config.ERC20Target, wallets []config.WalletTarget) (func(), error) {
+	col.mutex.RLock()
+	known := map[string]*contractInfo{}
+	for _, info := range col.contracts {
+		known[info.Address] = info
+	}
+	col.mutex.RUnlock()
+
+	var added []config.ERC20Target
+	for _, target := range contractAddresses {
+		if _, ok := known[common.HexToAddress(target.ContractAddr).Hex()]; !ok {
+			added = append(added, target)
+		}
+	}
+	addedInfos, err := getContractInfos(context.Background(), col.aggregator, added)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range addedInfos {
+		known[info.Address] = info
+	}
+
+	contracts := make([]*contractInfo, len(contractAddresses))
+	for i, target := range contractAddresses {
+		info := *known[common.HexToAddress(target.ContractAddr).Hex()]
+		info.Name = target.Name
+		contracts[i] = &info
+	}
+
+	return func() {
+		col.mutex.Lock()
+		defer col.mutex.Unlock()
+		col.contracts = contracts
+		col.wallets = wallets
+	}, nil
+}
+
+func (col *Tokens) Collect(ch chan<- prometheus.Metric) {
+	col.CollectContext(context.Background(), ch)
+}
+
+func (col *Tokens) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	col.mutex.RLock()
+	contracts, wallets := col.contracts, col.wallets
+	col.mutex.RUnlock()
+	if len(contracts) == 0 {
+		return
+	}
+
+	// the total supply of each contract, followed by the balance of each wallet
+	calls := make([]multicall.Call, 0, len(contracts)*(1+len(wallets)))
+	for _, info := range contracts {
+		address := common.HexToAddress(info.Address)
+		calls = append(calls, tokenCall(address, "totalSupply"))
+		for _, wallet := range wallets {
+			calls = append(calls, tokenCall(address, "balanceOf", common.HexToAddress(wallet.Addr)))
+		}
+	}
+
+	block, results, err := col.aggregator.Aggregate(ctx, calls)
+	if err != nil {
+		wErr := errors.Wrap(err, "failed to read ERC20 tokens")
+		ch <- prometheus.NewInvalidMetric(col.supplyDesc, wErr)
+		ch <- prometheus.NewInvalidMetric(col.balanceDesc, wErr)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(col.blockDesc, prometheus.GaugeValue, float64(block))
+
+	for _, info := range contracts {
+		supply, err := unpackAmount("totalSupply", results[0])
+		if err != nil {
+			wErr := errors.Wrapf(err, "failed to get total supply for %s", info.Address)
+			ch <- prometheus.NewInvalidMetric(col.supplyDesc, wErr)
+		} else {
+			ch <- prometheus.MustNewConstMetric(col.supplyDesc, prometheus.GaugeValue, tokenAmount(supply, info.Decimals),
+				info.Address, info.Symbol, info.Name)
+		}
+
+		for i, wallet := range wallets {
+			balance, err := unpackAmount("balanceOf", results[1+i])
+			if err != nil {
+				wErr := errors.Wrapf(err, "failed to get balance of %s for %s", wallet.Addr, info.Address)
+				ch <- prometheus.NewInvalidMetric(col.balanceDesc, wErr)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(col.balanceDesc, prometheus.GaugeValue, tokenAmount(balance, info.Decimals),
+				info.Address, info.Symbol, info.Name, wallet.Name)
+		}
+		results = results[1+len(wallets):]
+	}
+}
+
+// tokenAmount converts an amount in the smallest unit of a token to tokens.
+func tokenAmount(amount *big.Int, decimals uint8) float64 {
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), scale).Float64()
+	return value
+}
@@ -0,0 +1,96 @@
+package erc20
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/multicall"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+const (
+	mockContractAddress = "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+	mockWalletAddress   = "0x2D24Ce7A1aD2E0b7Ff3d1C8a3D5d3a0F0dD6fBc8"
+)
+
+// mockTokenClient serves a token with 2 decimals, on a chain without Multicall3.
+type mockTokenClient struct {
+	t *testing.T
+}
+
+func (c *mockTokenClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return 1234, nil
+}
+
+func (c *mockTokenClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *mockTokenClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber.Uint64() != 1234 {
+		c.t.Fatalf("got call at block %d, want 1234", blockNumber)
+	}
+	method, err := parsedTokenABI.MethodById(call.Data[:4])
+	if err != nil {
+		c.t.Fatalf("unexpected call: %v", err)
+	}
+	var out []byte
+	switch method.Name {
+	case "symbol":
+		out, err = method.Outputs.Pack("DAI")
+	case "decimals":
+		out, err = method.Outputs.Pack(uint8(2))
+	case "totalSupply":
+		out, err = method.Outputs.Pack(big.NewInt(1000050))
+	case "balanceOf":
+		owner := common.HexToAddress(mockWalletAddress).Hash().Bytes()
+		if !bytes.Equal(call.Data[4:], owner) {
+			c.t.Fatalf("got balanceOf for %x", call.Data[4:])
+		}
+		out, err = method.Outputs.Pack(big.NewInt(1234))
+	}
+	if err != nil {
+		c.t.Fatalf("failed to pack %s: %v", method.Name, err)
+	}
+	return out, nil
+}
+
+func TestTokens(t *testing.T) {
+	aggregator := multicall.NewAggregator(&mockTokenClient{t: t})
+	collector, err := NewERC20Tokens(aggregator,
+		[]config.ERC20Target{{Name: "dai", ContractAddr: mockContractAddress}},
+		[]config.WalletTarget{{Name: "wallet", Addr: mockWalletAddress}},
+		"mainnet")
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+
+	ch := make(chan prometheus.Metric, 3)
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %v, want 3", got)
+	}
+
+	want := map[*prometheus.Desc]float64{
+		collector.blockDesc:   1234,
+		collector.supplyDesc:  10000.5,
+		collector.balanceDesc: 12.34,
+	}
+	for result := range ch {
+		var metric dto.Metric
+		if err := result.Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := metric.GetGauge().GetValue(); got != want[result.Desc()] {
+			t.Fatalf("got %v for %s, want %v", got, result.Desc(), want[result.Desc()])
+		}
+	}
+}
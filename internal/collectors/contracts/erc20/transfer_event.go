@@ -2,14 +2,13 @@ package erc20
 
 import (
 	"context"
-	"github.com/thepalbi/ethereum-prometheus-exporter/clients/erc20"
 	"math"
 	"math/big"
-	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/clients/multicall"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
 )
@@ -18,15 +17,17 @@ type TransferEvent struct {
 	*Event
 }
 
-func NewERC20TransferEvent(client ContractClient, contractAddresses []config.ERC20Target, nowBlockNumber uint64, blockchain string) (*TransferEvent, error) {
-	clients, err := getContractClients(client, contractAddresses)
+func NewERC20TransferEvent(client ContractClient, aggregator *multicall.Aggregator, head BlockNumberGetter, contractAddresses []config.ERC20Target, nowBlockNumber uint64, blockchain string) (*TransferEvent, error) {
+	states, err := newContractStates(client, aggregator, contractAddresses, nowBlockNumber)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TransferEvent{
 		&Event{
-			contractClients: clients,
+			client:     client,
+			aggregator: aggregator,
+			contracts:  states,
 			desc: prometheus.NewDesc(
 				"erc20_transfer_event",
 				"ERC20 Transfer events count",
@@ -35,8 +36,7 @@ func NewERC20TransferEvent(client ContractClient, contractAddresses []config.ERC
 					constants.BlockchainNameLabel: blockchain,
 				},
 			),
-			lastQueriedBlock: nowBlockNumber,
-			bnGetter:         client,
+			bnGetter: head,
 		},
 	}, nil
 }
@@ -45,65 +45,38 @@ func (col *TransferEvent) Describe(ch chan<- *prometheus.Desc) {
 	ch <- col.desc
 }
 
-func (col *TransferEvent) doCollect(ch chan<- prometheus.Metric, currentBlockNumber uint64, info *contractInfo, client *erc20.ContractFilterer) {
-	it, err := client.FilterTransfer(&bind.FilterOpts{
-		Context: context.Background(),
-		Start:   col.lastQueriedBlock,
-		End:     &currentBlockNumber,
+func (col *TransferEvent) countEvents(ctx context.Context, state *contractState, end uint64) (uint64, float64, error) {
+	it, err := state.filterer.FilterTransfer(&bind.FilterOpts{
+		Context: ctx,
+		Start:   state.fromBlock,
+		End:     &end,
 	}, nil, nil)
 	if err != nil {
-		wErr := errors.Wrapf(err, "failed to create transfer iterator for contract=[%s]", info.Address)
-		ch <- prometheus.NewInvalidMetric(col.desc, wErr)
-		return
+		return 0, 0, errors.Wrapf(err, "failed to create transfer iterator for contract=[%s]", state.info.Address)
 	}
+	defer it.Close()
 
 	// histogram summary to collect
 	var count uint64 = 0
 	var sum float64 = 0
 
-	for {
-		eventsLeft := it.Next()
-		if !eventsLeft && it.Error() == nil {
-			// Finished reading events, advance lastQueriedBlock and publish histogram data
-			ch <- prometheus.MustNewConstHistogram(col.desc, count, sum, nil, info.Address, info.Symbol, info.Name)
-			return
-		} else if !eventsLeft {
-			wErr := errors.Wrapf(err, "failed to read transfer event for contract=[%s]", info.Address)
-			ch <- prometheus.NewInvalidMetric(col.desc, wErr)
-			return
-		}
+	for it.Next() {
 		te := it.Event
 
 		value, _ := new(big.Float).SetInt(te.Tokens).Float64()
 		count += 1
-		sum += value / math.Pow10(int(info.Decimals))
+		sum += value / math.Pow10(int(state.info.Decimals))
 	}
+	if err := it.Error(); err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to read transfer event for contract=[%s]", state.info.Address)
+	}
+	return count, sum, nil
 }
 
 func (col *TransferEvent) Collect(ch chan<- prometheus.Metric) {
-	col.collectMutex.Lock()
-	defer col.collectMutex.Unlock()
-
-	currentBlockNumber, err := col.bnGetter.BlockNumber(context.Background())
-	if err != nil {
-		wErr := errors.Wrap(err, "failed to get current block number")
-		ch <- prometheus.NewInvalidMetric(col.desc, wErr)
-		return
-	}
-	// INV: currentBlockNum >= lastQueriedblock
-
-	wg := sync.WaitGroup{}
-	for contrInfo, client := range col.contractClients {
-		wg.Add(1)
-
-		go func(contrInfo *contractInfo, client *erc20.ContractFilterer) {
-			defer wg.Done()
-			col.doCollect(ch, currentBlockNumber, contrInfo, client)
-		}(contrInfo, client)
-	}
-
-	wg.Wait()
+	col.CollectContext(context.Background(), ch)
+}
 
-	// Improve error model, this will advance last seen block even if some client fails
-	col.lastQueriedBlock = currentBlockNumber
+func (col *TransferEvent) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	col.collect(ctx, ch, col.countEvents)
 }
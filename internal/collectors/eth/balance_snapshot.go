@@ -0,0 +1,216 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+const (
+	scheduleLabel       = "schedule"
+	balanceSnapshotName = "eth_balance_snapshot"
+)
+
+var openMetricsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type balanceSnapshot struct {
+	point    snapshotPoint
+	balances map[common.Address]float64
+}
+
+// EthBalanceSnapshot exports wallet balances at fixed points in the chain history. Snapshots are queried with
+// eth_getBalance at a block number, which requires an archive node for anything but recent blocks.
+type EthBalanceSnapshot struct {
+	rpc        Caller
+	addresses  []WalletAddress
+	unit       units.Unit
+	blockchain string
+	schedule   *snapshotSchedule
+	desc       *prometheus.Desc
+	blockDesc  *prometheus.Desc
+	// mutex guards addresses and snapshots, and is never held across calls.
+	mutex sync.Mutex
+	// snapshots holds the balances of the latest snapshot of each schedule, so they're only queried once.
+	snapshots map[string]*balanceSnapshot
+}
+
+func NewEthBalanceSnapshot(rpc Caller, wallets []config.WalletTarget, blockInterval uint64, daily bool, unit units.Unit, blockchain string) *EthBalanceSnapshot {
+	return &EthBalanceSnapshot{
+		rpc:        rpc,
+		addresses:  newWalletAddresses(wallets),
+		unit:       unit,
+		blockchain: blockchain,
+		schedule: &snapshotSchedule{
+			rpc:           rpc,
+			blockInterval: blockInterval,
+			daily:         daily,
+		},
+		desc: prometheus.NewDesc(
+			balanceSnapshotName,
+			"balance at the latest snapshot block in "+string(unit),
+			[]string{constants.NameLabel, scheduleLabel},
+			map[string]string{
+				constants.BlockchainNameLabel: blockchain,
+			},
+		),
+		blockDesc: prometheus.NewDesc(
+			"eth_balance_snapshot_block",
+			"block number of the latest balance snapshot",
+			[]string{scheduleLabel},
+			map[string]string{
+				constants.BlockchainNameLabel: blockchain,
+			},
+		),
+		snapshots: map[string]*balanceSnapshot{},
+	}
+}
+
+func getBalanceAt(ctx context.Context, rpc Caller, address common.Address, block uint64) (*big.Int, error) {
+	var result hexutil.Big
+	if err := rpc.CallContext(ctx, &result, "eth_getBalance", address, hexutil.Uint64(block)); err != nil {
+		return nil, errors.Wrapf(err, "failed to get balance of %s at block %d", address.Hex(), block)
+	}
+	return result.ToInt(), nil
+}
+
+// SetWallets replaces the wallets whose balance is snapshotted. Balances already queried for the latest snapshots are
+// kept.
+func (collector *EthBalanceSnapshot) SetWallets(wallets []config.WalletTarget) {
+	addresses := newWalletAddresses(wallets)
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.addresses = addresses
+}
+
+func (collector *EthBalanceSnapshot) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+	ch <- collector.blockDesc
+}
+
+func (collector *EthBalanceSnapshot) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthBalanceSnapshot) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	collector.mutex.Lock()
+	addresses := collector.addresses
+	collector.mutex.Unlock()
+
+	head, err := getBlockHeader(ctx, collector.rpc, "latest")
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		ch <- prometheus.NewInvalidMetric(collector.blockDesc, err)
+		return
+	}
+
+	points, err := collector.schedule.latest(ctx, head)
+	if err != nil {
+		wErr := errors.Wrap(err, "failed to resolve snapshot blocks")
+		ch <- prometheus.NewInvalidMetric(collector.desc, wErr)
+		ch <- prometheus.NewInvalidMetric(collector.blockDesc, wErr)
+		return
+	}
+
+	for _, p := range points {
+		snapshot := collector.snapshot(p)
+		ch <- prometheus.MustNewConstMetric(collector.blockDesc, prometheus.GaugeValue, float64(p.block), p.schedule)
+		for _, add := range addresses {
+			collector.mutex.Lock()
+			balance, ok := snapshot.balances[add.Address]
+			collector.mutex.Unlock()
+			if !ok {
+				wei, err := getBalanceAt(ctx, collector.rpc, add.Address, p.block)
+				if err != nil {
+					ch <- prometheus.NewInvalidMetric(collector.desc, err)
+					continue
+				}
+				balance = collector.unit.FromWei(wei)
+				collector.mutex.Lock()
+				snapshot.balances[add.Address] = balance
+				collector.mutex.Unlock()
+			}
+			ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, balance, add.Name, p.schedule)
+		}
+	}
+}
+
+// snapshot returns the snapshot of the schedule of p, replacing it once the schedule moved to another block.
+func (collector *EthBalanceSnapshot) snapshot(p snapshotPoint) *balanceSnapshot {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	snapshot := collector.snapshots[p.schedule]
+	if snapshot == nil || snapshot.point.block != p.block {
+		snapshot = &balanceSnapshot{point: p, balances: map[common.Address]float64{}}
+		collector.snapshots[p.schedule] = snapshot
+	}
+	return snapshot
+}
+
+// DayStartBlock returns the first block of the UTC day daysAgo days before the one of the latest block, to backfill
+// the snapshots of the last days.
+func (collector *EthBalanceSnapshot) DayStartBlock(ctx context.Context, daysAgo uint64) (uint64, error) {
+	head, err := getBlockHeader(ctx, collector.rpc, "latest")
+	if err != nil {
+		return 0, err
+	}
+	return collector.schedule.dailyBlock(ctx, dayStart(uint64(head.Timestamp), daysAgo), 0, uint64(head.Number))
+}
+
+// Backfill writes the balance snapshots taken from block from up to the latest block to w, in the OpenMetrics text
+// format. The output can be imported with `promtool tsdb create-blocks-from openmetrics`.
+func (collector *EthBalanceSnapshot) Backfill(ctx context.Context, from uint64, w io.Writer) error {
+	collector.mutex.Lock()
+	addresses := collector.addresses
+	collector.mutex.Unlock()
+
+	head, err := getBlockHeader(ctx, collector.rpc, "latest")
+	if err != nil {
+		return err
+	}
+	start, err := getBlockHeader(ctx, collector.rpc, hexutil.Uint64(from))
+	if err != nil {
+		return err
+	}
+
+	points, err := collector.schedule.between(ctx, start, head)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve snapshot blocks")
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP %s balance at snapshot blocks in %s\n# TYPE %s gauge\n", balanceSnapshotName, collector.unit, balanceSnapshotName); err != nil {
+		return err
+	}
+	for _, p := range points {
+		for _, add := range addresses {
+			wei, err := getBalanceAt(ctx, collector.rpc, add.Address, p.block)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "%s{%s=\"%s\",%s=\"%s\",%s=\"%s\"} %s %d\n",
+				balanceSnapshotName,
+				constants.BlockchainNameLabel, openMetricsEscaper.Replace(collector.blockchain),
+				constants.NameLabel, openMetricsEscaper.Replace(add.Name),
+				scheduleLabel, p.schedule,
+				strconv.FormatFloat(collector.unit.FromWei(wei), 'g', -1, 64),
+				p.timestamp,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = fmt.Fprintln(w, "# EOF")
+	return err
+}
@@ -0,0 +1,156 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+const (
+	mockHeadBlock = 1050
+	// mockDayStartBlock is the first block of the mock chain's last UTC day.
+	mockDayStartBlock = 500
+)
+
+func mockBlockTimestamp(number uint64) uint64 {
+	return 10*secondsPerDay - mockDayStartBlock*12 + number*12
+}
+
+// newMockChainServer serves a chain with a block every 12 seconds, where every wallet's balance at block n is n wei.
+func newMockChainServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Method string
+			Params []json.RawMessage
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %#v", err)
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			number := uint64(mockHeadBlock)
+			if string(req.Params[0]) != `"latest"` {
+				var n hexutil.Uint64
+				if err := json.Unmarshal(req.Params[0], &n); err != nil {
+					t.Fatalf("unexpected block number %s", req.Params[0])
+				}
+				number = uint64(n)
+			}
+			result = map[string]hexutil.Uint64{
+				"number":    hexutil.Uint64(number),
+				"timestamp": hexutil.Uint64(mockBlockTimestamp(number)),
+			}
+		case "eth_getBalance":
+			var n hexutil.Uint64
+			if err := json.Unmarshal(req.Params[1], &n); err != nil {
+				t.Fatalf("unexpected block number %s", req.Params[1])
+			}
+			result = n
+		default:
+			t.Fatalf("unexpected method %s", req.Method)
+		}
+
+		response, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		if _, err := w.Write(response); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+}
+
+func TestEthBalanceSnapshotCollect(t *testing.T) {
+	rpcServer := newMockChainServer(t)
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthBalanceSnapshot(rpc, []config.WalletTarget{{Addr: mockWalletAddress, Name: mockWalletName}}, 100, true, units.Wei, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 4)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 4 {
+		t.Fatalf("got %v, want 4", got)
+	}
+
+	// block and balance per schedule, where the balance equals the snapshot block number
+	expected := []float64{1000, 1000, mockDayStartBlock, mockDayStartBlock}
+	for _, want := range expected {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEthBalanceSnapshotBackfill(t *testing.T) {
+	rpcServer := newMockChainServer(t)
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthBalanceSnapshot(rpc, []config.WalletTarget{{Addr: mockWalletAddress, Name: mockWalletName}}, 100, true, units.Wei, mockBlockchainName)
+
+	var out bytes.Buffer
+	if err := collector.Backfill(context.Background(), 400, &out); err != nil {
+		t.Fatalf("backfill failed: %#v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	// HELP, TYPE, 7 block interval snapshots, 1 daily snapshot and EOF
+	if got := len(lines); got != 11 {
+		t.Fatalf("got %d lines, want 11:\n%s", got, out.String())
+	}
+	want := fmt.Sprintf(`eth_balance_snapshot{blockchain="%s",name="%s",schedule="daily"} 500 %d`, mockBlockchainName, mockWalletName, mockBlockTimestamp(mockDayStartBlock))
+	if got := lines[9]; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := lines[10]; got != "# EOF" {
+		t.Fatalf("got %q, want # EOF", got)
+	}
+}
+
+func TestEthBalanceSnapshotDayStartBlock(t *testing.T) {
+	rpcServer := newMockChainServer(t)
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthBalanceSnapshot(rpc, []config.WalletTarget{{Addr: mockWalletAddress, Name: mockWalletName}}, 100, true, units.Wei, mockBlockchainName)
+	for daysAgo, want := range []uint64{mockDayStartBlock, 0} {
+		got, err := collector.DayStartBlock(context.Background(), uint64(daysAgo))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Fatalf("got block %d for %d days ago, want %d", got, daysAgo, want)
+		}
+	}
+}
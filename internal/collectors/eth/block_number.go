@@ -1,18 +1,31 @@
 package eth
 
 import (
+	"context"
+	"strings"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/rpcerr"
+)
+
+// Block tags accepted by eth_getBlockByNumber.
+const (
+	LatestBlockTag    = "latest"
+	SafeBlockTag      = "safe"
+	FinalizedBlockTag = "finalized"
 )
 
 type EthBlockNumber struct {
-	rpc  *rpc.Client
-	desc *prometheus.Desc
+	rpc           Caller
+	desc          *prometheus.Desc
+	safeDesc      *prometheus.Desc
+	finalizedDesc *prometheus.Desc
+	lagDesc       *prometheus.Desc
 }
 
-func NewEthBlockNumber(rpc *rpc.Client, blockchain string) *EthBlockNumber {
+func NewEthBlockNumber(rpc Caller, blockchain string) *EthBlockNumber {
 	return &EthBlockNumber{
 		rpc: rpc,
 		desc: prometheus.NewDesc(
@@ -21,20 +34,103 @@ func NewEthBlockNumber(rpc *rpc.Client, blockchain string) *EthBlockNumber {
 			nil,
 			map[string]string{constants.BlockchainNameLabel: blockchain},
 		),
+		safeDesc: prometheus.NewDesc(
+			"eth_safe_block_number",
+			"number of the most recent safe block",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		finalizedDesc: prometheus.NewDesc(
+			"eth_finalized_block_number",
+			"number of the most recent finalized block",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		lagDesc: prometheus.NewDesc(
+			"eth_finalized_block_lag",
+			"number of blocks between the most recent and the most recent finalized block",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+// BlockNumberByTag returns the number of the block with the given tag.
+func BlockNumberByTag(ctx context.Context, rpc Caller, tag string) (uint64, error) {
+	block, err := getBlockHeader(ctx, rpc, tag)
+	if err != nil {
+		return 0, err
 	}
+	return uint64(block.Number), nil
 }
 
 func (collector *EthBlockNumber) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.desc
+	ch <- collector.safeDesc
+	ch <- collector.finalizedDesc
+	ch <- collector.lagDesc
 }
 
 func (collector *EthBlockNumber) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthBlockNumber) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result hexutil.Uint64
-	if err := collector.rpc.Call(&result, "eth_blockNumber"); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "eth_blockNumber"); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
 
 	value := float64(result)
 	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, value)
+
+	// pre-Merge chains and older clients reject the safe and finalized tags, in which case they are left out
+	if safe, ok := collector.collectTag(ctx, ch, collector.safeDesc, SafeBlockTag); ok {
+		ch <- prometheus.MustNewConstMetric(collector.safeDesc, prometheus.GaugeValue, float64(safe))
+	}
+	if finalized, ok := collector.collectTag(ctx, ch, collector.finalizedDesc, FinalizedBlockTag); ok {
+		ch <- prometheus.MustNewConstMetric(collector.finalizedDesc, prometheus.GaugeValue, float64(finalized))
+		lag := float64(0)
+		if uint64(result) > finalized {
+			lag = float64(uint64(result) - finalized)
+		}
+		ch <- prometheus.MustNewConstMetric(collector.lagDesc, prometheus.GaugeValue, lag)
+	}
+}
+
+// collectTag returns the number of the block with the given tag, and false if it could not be retrieved. An invalid
+// metric is sent for any error other than the node rejecting the tag.
+func (collector *EthBlockNumber) collectTag(ctx context.Context, ch chan<- prometheus.Metric, desc *prometheus.Desc, tag string) (uint64, bool) {
+	number, err := BlockNumberByTag(ctx, collector.rpc, tag)
+	if err != nil {
+		if !isUnsupportedTag(err) {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+		}
+		return 0, false
+	}
+	return number, true
+}
+
+// unsupportedTagMessages are the messages of nodes not having a block for a tag, such as geth before the merge.
+var unsupportedTagMessages = []string{"unknown block", "finalized block not found", "safe block not found"}
+
+// isUnsupportedTag returns whether err is the node rejecting a block tag, either as an invalid parameter, by not
+// serving the method, or as a missing block.
+func isUnsupportedTag(err error) bool {
+	code, ok := rpcerr.Code(err)
+	if !ok {
+		return false
+	}
+	switch code {
+	case rpcerr.InvalidParamsCode, rpcerr.MethodNotFoundCode:
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, unsupported := range unsupportedTagMessages {
+		if strings.Contains(message, unsupported) {
+			return true
+		}
+	}
+	return false
 }
@@ -1,6 +1,8 @@
 package eth
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -39,13 +41,38 @@ func TestEthBlockNumberCollectError(t *testing.T) {
 	}
 }
 
-func TestEthBlockNumberCollect(t *testing.T) {
-	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := w.Write([]byte(`{"result": "0xc94"}"`))
-		if err != nil {
+// newMockBlockTagServer answers eth_blockNumber with latest, and eth_getBlockByNumber with the block of each tag in
+// blocks, rejecting any other tag.
+func newMockBlockTagServer(t *testing.T, latest string, blocks map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Method string
+			Params []json.RawMessage
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %#v", err)
+		}
+
+		response := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": %q}`, req.ID, latest)
+		if req.Method == "eth_getBlockByNumber" {
+			response = fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": -32602, "message": "invalid argument 0: hex string without 0x prefix"}}`, req.ID)
+			var tag string
+			if err := json.Unmarshal(req.Params[0], &tag); err != nil {
+				t.Fatalf("could not decode block tag: %#v", err)
+			}
+			if number, ok := blocks[tag]; ok {
+				response = fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": {"number": %q, "timestamp": "0x0"}}`, req.ID, number)
+			}
+		}
+		if _, err := w.Write([]byte(response)); err != nil {
 			t.Fatalf("could not write a response: %#v", err)
 		}
 	}))
+}
+
+func TestEthBlockNumberCollect(t *testing.T) {
+	rpcServer := newMockBlockTagServer(t, "0xc94", map[string]string{})
 	defer rpcServer.Close()
 
 	rpc, err := rpc.DialHTTP(rpcServer.URL)
@@ -76,3 +103,129 @@ func TestEthBlockNumberCollect(t *testing.T) {
 		}
 	}
 }
+
+func TestEthBlockNumberCollectTags(t *testing.T) {
+	rpcServer := newMockBlockTagServer(t, "0xc94", map[string]string{
+		SafeBlockTag:      "0xc80",
+		FinalizedBlockTag: "0xc74",
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthBlockNumber(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 4)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 4 {
+		t.Fatalf("got %v, want 4", got)
+	}
+
+	// latest, safe, finalized and the lag between latest and finalized
+	for _, want := range []float64{3220, 3200, 3188, 32} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEthBlockNumberCollectTagsError(t *testing.T) {
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Method string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %#v", err)
+		}
+
+		response := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": "0xc94"}`, req.ID)
+		if req.Method == "eth_getBlockByNumber" {
+			response = fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": -32000, "message": "header not found"}}`, req.ID)
+		}
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthBlockNumber(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	// only rejected tags are left out, other errors are reported for the safe and finalized blocks
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %v, want 3", got)
+	}
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	for result := range ch {
+		if err := result.Write(&metric); err == nil {
+			t.Fatalf("expected invalid metric, got %#v", metric)
+		}
+	}
+}
+
+func TestEthBlockNumberCollectTagsNotFound(t *testing.T) {
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Method string
+			Params []json.RawMessage
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %#v", err)
+		}
+
+		response := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": "0xc94"}`, req.ID)
+		if req.Method == "eth_getBlockByNumber" {
+			var tag string
+			if err := json.Unmarshal(req.Params[0], &tag); err != nil {
+				t.Fatalf("could not decode block tag: %#v", err)
+			}
+			// the errors of geth before the merge
+			response = fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": -32000, "message": "%s block not found"}}`, req.ID, tag)
+		}
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthBlockNumber(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+}
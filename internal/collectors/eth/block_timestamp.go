@@ -1,22 +1,24 @@
 package eth
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 )
 
 type EthBlockTimestamp struct {
-	rpc  *rpc.Client
+	rpc  Caller
 	desc *prometheus.Desc
 }
 
 type blockResult struct {
+	Number    hexutil.Uint64
 	Timestamp hexutil.Uint64
 }
 
-func NewEthBlockTimestamp(rpc *rpc.Client, blockchain string) *EthBlockTimestamp {
+func NewEthBlockTimestamp(rpc Caller, blockchain string) *EthBlockTimestamp {
 	return &EthBlockTimestamp{
 		rpc: rpc,
 		desc: prometheus.NewDesc(
@@ -33,9 +35,13 @@ func (collector *EthBlockTimestamp) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (collector *EthBlockTimestamp) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthBlockTimestamp) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result *blockResult
 
-	if err := collector.rpc.Call(&result, "eth_getBlockByNumber", "latest", false); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "eth_getBlockByNumber", "latest", false); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
@@ -0,0 +1,8 @@
+package eth
+
+import "context"
+
+// Caller sends JSON-RPC calls, either an *rpc.Client or a provider.Batcher grouping the calls of a scrape.
+type Caller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
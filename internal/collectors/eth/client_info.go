@@ -0,0 +1,78 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/rpcerr"
+)
+
+type EthClientInfo struct {
+	rpc  Caller
+	desc *prometheus.Desc
+}
+
+func NewEthClientInfo(rpc Caller, blockchain string) *EthClientInfo {
+	return &EthClientInfo{
+		rpc: rpc,
+		desc: prometheus.NewDesc(
+			"eth_client_info",
+			"client version and chain identity of the node",
+			[]string{"client_version", "chain_id", "network_id", "protocol_version"},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+// ChainID returns the chain id served by the node through eth_chainId.
+func ChainID(ctx context.Context, rpc Caller) (*big.Int, error) {
+	var result hexutil.Big
+	if err := rpc.CallContext(ctx, &result, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return result.ToInt(), nil
+}
+
+func (collector *EthClientInfo) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+}
+
+func (collector *EthClientInfo) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthClientInfo) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	var clientVersion string
+	if err := collector.rpc.CallContext(ctx, &clientVersion, "web3_clientVersion"); err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		return
+	}
+
+	chainID, err := ChainID(ctx, collector.rpc)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		return
+	}
+
+	var networkID string
+	if err := collector.rpc.CallContext(ctx, &networkID, "net_version"); err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		return
+	}
+
+	// eth_protocolVersion was dropped by some clients, in which case the label is left empty.
+	var protocolVersion hexutil.Uint64
+	var protocol string
+	if err := collector.rpc.CallContext(ctx, &protocolVersion, "eth_protocolVersion"); err == nil {
+		protocol = strconv.FormatUint(uint64(protocolVersion), 10)
+	} else if !rpcerr.IsMethodNotFound(err) {
+		ch <- prometheus.NewInvalidMetric(collector.desc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, 1, clientVersion, chainID.String(), networkID, protocol)
+}
@@ -0,0 +1,135 @@
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newMockMethodServer answers each JSON-RPC method with the given raw result, and with a method not found error for
+// any other method.
+func newMockMethodServer(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Method string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %#v", err)
+		}
+
+		response := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": -32601, "message": "the method %s does not exist/is not available"}}`, req.ID, req.Method)
+		if result, ok := results[req.Method]; ok {
+			response = fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": %s}`, req.ID, result)
+		}
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+}
+
+func TestEthClientInfoCollectError(t *testing.T) {
+	rpc, err := rpc.DialHTTP("http://localhost")
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthClientInfo(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 1)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+
+	var metric dto.Metric
+	for result := range ch {
+		err := result.Write(&metric)
+		if err == nil {
+			t.Fatalf("expected invalid metric, got %#v", metric)
+		}
+		if _, ok := err.(*url.Error); !ok {
+			t.Fatalf("unexpected error %#v", err)
+		}
+	}
+}
+
+func TestEthClientInfoCollect(t *testing.T) {
+	cases := []struct {
+		name     string
+		results  map[string]string
+		protocol string
+	}{
+		{
+			name: "with protocol version",
+			results: map[string]string{
+				"web3_clientVersion":  `"Geth/v1.10.16-stable/linux-amd64/go1.17"`,
+				"eth_chainId":         `"0x5"`,
+				"net_version":         `"5"`,
+				"eth_protocolVersion": `"0x42"`,
+			},
+			protocol: "66",
+		},
+		{
+			name: "without protocol version",
+			results: map[string]string{
+				"web3_clientVersion": `"Geth/v1.10.16-stable/linux-amd64/go1.17"`,
+				"eth_chainId":        `"0x5"`,
+				"net_version":        `"5"`,
+			},
+			protocol: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rpcServer := newMockMethodServer(t, c.results)
+			defer rpcServer.Close()
+
+			rpc, err := rpc.DialHTTP(rpcServer.URL)
+			if err != nil {
+				t.Fatalf("rpc connection error: %#v", err)
+			}
+
+			collector := NewEthClientInfo(rpc, mockBlockchainName)
+			ch := make(chan prometheus.Metric, 1)
+
+			collector.Collect(ch)
+			close(ch)
+
+			if got := len(ch); got != 1 {
+				t.Fatalf("got %v, want 1", got)
+			}
+
+			var metric dto.Metric
+			if err := (<-ch).Write(&metric); err != nil {
+				t.Fatalf("expected metric, got %#v", err)
+			}
+			labels := map[string]string{}
+			for _, l := range metric.Label {
+				labels[l.GetName()] = l.GetValue()
+			}
+			expected := map[string]string{
+				"blockchain":       mockBlockchainName,
+				"client_version":   "Geth/v1.10.16-stable/linux-amd64/go1.17",
+				"chain_id":         "5",
+				"network_id":       "5",
+				"protocol_version": c.protocol,
+			}
+			for name, want := range expected {
+				if got := labels[name]; got != want {
+					t.Fatalf("got %v, want %v for label %s", got, want, name)
+				}
+			}
+		})
+	}
+}
@@ -1,18 +1,19 @@
 package eth
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 )
 
 type EthEarliestBlockTransactions struct {
-	rpc  *rpc.Client
+	rpc  Caller
 	desc *prometheus.Desc
 }
 
-func NewEthEarliestBlockTransactions(rpc *rpc.Client, blockchain string) *EthEarliestBlockTransactions {
+func NewEthEarliestBlockTransactions(rpc Caller, blockchain string) *EthEarliestBlockTransactions {
 	return &EthEarliestBlockTransactions{
 		rpc: rpc,
 		desc: prometheus.NewDesc(
@@ -29,8 +30,12 @@ func (collector *EthEarliestBlockTransactions) Describe(ch chan<- *prometheus.De
 }
 
 func (collector *EthEarliestBlockTransactions) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthEarliestBlockTransactions) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result hexutil.Uint64
-	if err := collector.rpc.Call(&result, "eth_getBlockTransactionCountByNumber", "earliest"); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "eth_getBlockTransactionCountByNumber", "earliest"); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
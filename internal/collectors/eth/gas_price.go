@@ -1,43 +1,66 @@
 package eth
 
 import (
+	"context"
+
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
-	"math/big"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type EthGasPrice struct {
-	rpc  *rpc.Client
+	rpc  Caller
+	unit units.Unit
 	desc *prometheus.Desc
+	// infoDesc is only set when the exact gas price is exported.
+	infoDesc *prometheus.Desc
 }
 
-func NewEthGasPrice(rpc *rpc.Client, blockchain string) *EthGasPrice {
-	return &EthGasPrice{
-		rpc: rpc,
+func NewEthGasPrice(rpc Caller, unit units.Unit, exactInfo bool, blockchain string) *EthGasPrice {
+	collector := &EthGasPrice{
+		rpc:  rpc,
+		unit: unit,
 		desc: prometheus.NewDesc(
 			"eth_gas_price",
-			"current gas price in wei",
+			"current gas price in "+string(unit),
 			nil,
 			map[string]string{constants.BlockchainNameLabel: blockchain},
 		),
 	}
+	if exactInfo {
+		collector.infoDesc = prometheus.NewDesc(
+			"eth_gas_price_info",
+			"exact current gas price in wei, exposed as a label",
+			[]string{constants.WeiLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		)
+	}
+	return collector
 }
 
 func (collector *EthGasPrice) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.desc
+	if collector.infoDesc != nil {
+		ch <- collector.infoDesc
+	}
 }
 
 func (collector *EthGasPrice) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthGasPrice) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result hexutil.Big
-	if err := collector.rpc.Call(&result, "eth_gasPrice"); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "eth_gasPrice"); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
 
-	i := (*big.Int)(&result)
-	value, _ := new(big.Float).SetInt(i).Float64()
+	value := collector.unit.FromWei(result.ToInt())
 	ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, value)
+	if collector.infoDesc != nil {
+		ch <- prometheus.MustNewConstMetric(collector.infoDesc, prometheus.GaugeValue, 1, result.ToInt().String())
+	}
 }
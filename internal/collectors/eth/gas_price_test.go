@@ -1,14 +1,18 @@
 package eth
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
 )
 
 func TestEthGasPriceCollectError(t *testing.T) {
@@ -17,7 +21,7 @@ func TestEthGasPriceCollectError(t *testing.T) {
 		t.Fatalf("rpc connection error: %#v", err)
 	}
 
-	collector := NewEthGasPrice(rpc, mockBlockchainName)
+	collector := NewEthGasPrice(rpc, units.Wei, false, mockBlockchainName)
 	ch := make(chan prometheus.Metric, 1)
 
 	collector.Collect(ch)
@@ -39,6 +43,35 @@ func TestEthGasPriceCollectError(t *testing.T) {
 	}
 }
 
+func TestEthGasPriceCollectContextTimeout(t *testing.T) {
+	hang := make(chan struct{})
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer rpcServer.Close()
+	defer close(hang)
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthGasPrice(rpc, units.Wei, false, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	collector.CollectContext(ctx, ch)
+	close(ch)
+
+	var metric dto.Metric
+	for result := range ch {
+		if err := result.Write(&metric); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("got %v, want the deadline of the scrape to be exceeded", err)
+		}
+	}
+}
+
 func TestEthGasPriceCollect(t *testing.T) {
 	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, err := w.Write([]byte(`{"result": "0x9184e72a000"}"`))
@@ -53,7 +86,7 @@ func TestEthGasPriceCollect(t *testing.T) {
 		t.Fatalf("rpc connection error: %#v", err)
 	}
 
-	collector := NewEthGasPrice(rpc, mockBlockchainName)
+	collector := NewEthGasPrice(rpc, units.Wei, false, mockBlockchainName)
 	ch := make(chan prometheus.Metric, 1)
 
 	collector.Collect(ch)
@@ -76,3 +109,47 @@ func TestEthGasPriceCollect(t *testing.T) {
 		}
 	}
 }
+
+func TestEthGasPriceCollectInGweiWithInfo(t *testing.T) {
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"result": "0x9184e72a001"}"`))
+		if err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthGasPrice(rpc, units.Gwei, true, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 2)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := *metric.Gauge.Value; got != 10000.000000001 {
+		t.Fatalf("got %v, want 10000.000000001", got)
+	}
+
+	metric = dto.Metric{}
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := len(metric.Label); got != 2 {
+		t.Fatalf("expected 2 labels, got %d", got)
+	}
+	if got := metric.Label[1].GetValue(); got != "10000000000001" {
+		t.Fatalf("got %v, want 10000000000001", got)
+	}
+}
@@ -1,16 +1,16 @@
 package eth
 
 import (
-	"math/big"
+	"context"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
 )
 
 type WalletAddress struct {
@@ -19,48 +19,90 @@ type WalletAddress struct {
 }
 
 type EthGetBalance struct {
-	rpc       *rpc.Client
+	rpc       Caller
+	mutex     sync.RWMutex
 	addresses []WalletAddress
+	unit      units.Unit
 	desc      *prometheus.Desc
+	// infoDesc is only set when exact balances are exported.
+	infoDesc *prometheus.Desc
 }
 
-func NewEthGetBalance(rpc *rpc.Client, wallets []config.WalletTarget, blockchain string) *EthGetBalance {
+func newWalletAddresses(wallets []config.WalletTarget) []WalletAddress {
 	var walletAddresses []WalletAddress
 	for _, w := range wallets {
 		walletAddresses = append(walletAddresses, WalletAddress{w.Name, common.HexToAddress(w.Addr)})
 	}
-	return &EthGetBalance{
+	return walletAddresses
+}
+
+func NewEthGetBalance(rpc Caller, wallets []config.WalletTarget, unit units.Unit, exactInfo bool, blockchain string) *EthGetBalance {
+	collector := &EthGetBalance{
 		rpc:       rpc,
-		addresses: walletAddresses,
+		addresses: newWalletAddresses(wallets),
+		unit:      unit,
 		desc: prometheus.NewDesc(
 			"eth_get_balance",
-			"get balance",
+			"get balance in "+string(unit),
 			[]string{constants.NameLabel},
 			map[string]string{
 				constants.BlockchainNameLabel: blockchain,
 			},
 		),
 	}
+	if exactInfo {
+		collector.infoDesc = prometheus.NewDesc(
+			"eth_get_balance_info",
+			"exact balance in wei, exposed as a label",
+			[]string{constants.NameLabel, constants.WeiLabel},
+			map[string]string{
+				constants.BlockchainNameLabel: blockchain,
+			},
+		)
+	}
+	return collector
 }
 
 func (collector *EthGetBalance) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.desc
+	if collector.infoDesc != nil {
+		ch <- collector.infoDesc
+	}
+}
+
+// SetWallets replaces the wallets whose balance is collected.
+func (collector *EthGetBalance) SetWallets(wallets []config.WalletTarget) {
+	addresses := newWalletAddresses(wallets)
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.addresses = addresses
 }
 
 func (collector *EthGetBalance) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthGetBalance) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	collector.mutex.RLock()
+	addresses := collector.addresses
+	collector.mutex.RUnlock()
+
 	wg := sync.WaitGroup{}
-	for _, add := range collector.addresses {
+	for _, add := range addresses {
 		wg.Add(1)
 		go func(add WalletAddress) {
 			defer wg.Done()
 			var result hexutil.Big
-			if err := collector.rpc.Call(&result, "eth_getBalance", add.Address, "latest"); err != nil {
+			if err := collector.rpc.CallContext(ctx, &result, "eth_getBalance", add.Address, "latest"); err != nil {
 				wErr := errors.Wrap(err, "failed to get Balance")
 				ch <- prometheus.NewInvalidMetric(collector.desc, wErr)
 				return
 			}
-			balance, _ := new(big.Float).SetInt(result.ToInt()).Float64()
+			balance := collector.unit.FromWei(result.ToInt())
 			ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, balance, add.Name)
+			if collector.infoDesc != nil {
+				ch <- prometheus.MustNewConstMetric(collector.infoDesc, prometheus.GaugeValue, 1, add.Name, result.ToInt().String())
+			}
 		}(add)
 	}
 	wg.Wait()
@@ -1,9 +1,12 @@
 package eth
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +14,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/provider"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/scrape"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
 )
 
 func TestEthGetBalance(t *testing.T) {
@@ -27,7 +33,7 @@ func TestEthGetBalance(t *testing.T) {
 		t.Fatalf("rpc connection error: %#v", err)
 	}
 
-	collector := NewEthGetBalance(rpc, []config.WalletTarget{{Addr: mockWalletAddress, Name: mockWalletName}}, mockBlockchainName)
+	collector := NewEthGetBalance(rpc, []config.WalletTarget{{Addr: mockWalletAddress, Name: mockWalletName}}, units.Wei, false, mockBlockchainName)
 	ch := make(chan prometheus.Metric, 1)
 
 	collector.Collect(ch)
@@ -72,7 +78,7 @@ func TestEthGetBalanceMultipleWallets(t *testing.T) {
 	collector := NewEthGetBalance(rpc, []config.WalletTarget{
 		{Addr: mockWalletAddress, Name: mockWalletName},
 		{Addr: mockWallet2Address, Name: mockWallet2Name},
-	}, mockBlockchainName)
+	}, units.Wei, false, mockBlockchainName)
 	ch := make(chan prometheus.Metric, 2)
 
 	collector.Collect(ch)
@@ -99,3 +105,123 @@ func TestEthGetBalanceMultipleWallets(t *testing.T) {
 		}
 	}
 }
+
+func TestEthGetBalanceInEtherWithInfo(t *testing.T) {
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(fmt.Sprintf(`{"result": "%s"}"`, mockResult)))
+		if err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthGetBalance(rpc, []config.WalletTarget{{Addr: mockWalletAddress, Name: mockWalletName}}, units.Ether, true, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 2)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := *metric.Gauge.Value; got != 1.70878393356434932 {
+		t.Fatalf("got %v, want 1.70878393356434932", got)
+	}
+
+	metric = dto.Metric{}
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := len(metric.Label); got != 3 {
+		t.Fatalf("expected 3 labels, got %d", got)
+	}
+	if got := metric.Label[2].GetValue(); got != "1708783933564349320" {
+		t.Fatalf("got %v, want 1708783933564349320", got)
+	}
+}
+
+func TestEthGetBalanceCachedAtHead(t *testing.T) {
+	var mutex sync.Mutex
+	head := "0x0000000000000000000000000000000000000000000000000000000000000001"
+	calls := map[string]int{}
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			t.Fatalf("could not decode the request: %v", err)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls[call.Method]++
+
+		var result string
+		switch call.Method {
+		case "eth_getBlockByNumber":
+			result = fmt.Sprintf(`{"hash": "%s", "number": "0x10", "timestamp": "0x5"}`, head)
+		case "eth_getBalance":
+			if want := fmt.Sprintf(`{"blockHash":"%s"}`, head); string(call.Params[1]) != want {
+				t.Fatalf("got balance at %s, want %s", call.Params[1], want)
+			}
+			result = fmt.Sprintf(`"%s"`, mockResult)
+		case "eth_getBlockTransactionCountByHash":
+			result = `"0xc94"`
+		default:
+			t.Fatalf("unexpected call to %s", call.Method)
+		}
+		if _, err := fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": 1, "result": %s}`, result); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+	cache := provider.NewCache(rpc, 0)
+
+	registry := scrape.NewRegistry()
+	registry.MustRegister(
+		NewEthGetBalance(cache, []config.WalletTarget{{Addr: mockWalletAddress, Name: mockWalletName}}, units.Wei, false, mockBlockchainName),
+		NewEthLatestBlockTransactions(cache, mockBlockchainName),
+		NewEthBlockTimestamp(cache, mockBlockchainName),
+	)
+	gather := func() {
+		families, err := registry.Gatherer(context.Background()).Gather()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := len(families); got != 3 {
+			t.Fatalf("got %d metric families, want 3", got)
+		}
+	}
+
+	// the head is resolved once per scrape, and the reads at it are kept until it moves
+	gather()
+	gather()
+	want := map[string]int{"eth_getBlockByNumber": 2, "eth_getBalance": 1, "eth_getBlockTransactionCountByHash": 1}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Fatalf("got calls %v, want %v", calls, want)
+	}
+
+	mutex.Lock()
+	head = "0x0000000000000000000000000000000000000000000000000000000000000002"
+	mutex.Unlock()
+	gather()
+	want = map[string]int{"eth_getBlockByNumber": 3, "eth_getBalance": 2, "eth_getBlockTransactionCountByHash": 2}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Fatalf("got calls %v once the head moved, want %v", calls, want)
+	}
+}
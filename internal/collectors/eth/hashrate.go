@@ -1,18 +1,19 @@
 package eth
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 )
 
 type EthHashrate struct {
-	rpc  *rpc.Client
+	rpc  Caller
 	desc *prometheus.Desc
 }
 
-func NewEthHashrate(rpc *rpc.Client, blockchain string) *EthHashrate {
+func NewEthHashrate(rpc Caller, blockchain string) *EthHashrate {
 	return &EthHashrate{
 		rpc: rpc,
 		desc: prometheus.NewDesc(
@@ -29,8 +30,12 @@ func (collector *EthHashrate) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (collector *EthHashrate) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthHashrate) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result hexutil.Uint64
-	if err := collector.rpc.Call(&result, "eth_hashrate"); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "eth_hashrate"); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
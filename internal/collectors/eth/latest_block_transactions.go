@@ -1,18 +1,19 @@
 package eth
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 )
 
 type EthLatestBlockTransactions struct {
-	rpc  *rpc.Client
+	rpc  Caller
 	desc *prometheus.Desc
 }
 
-func NewEthLatestBlockTransactions(rpc *rpc.Client, blockchain string) *EthLatestBlockTransactions {
+func NewEthLatestBlockTransactions(rpc Caller, blockchain string) *EthLatestBlockTransactions {
 	return &EthLatestBlockTransactions{
 		rpc: rpc,
 		desc: prometheus.NewDesc(
@@ -29,8 +30,12 @@ func (collector *EthLatestBlockTransactions) Describe(ch chan<- *prometheus.Desc
 }
 
 func (collector *EthLatestBlockTransactions) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthLatestBlockTransactions) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result hexutil.Uint64
-	if err := collector.rpc.Call(&result, "eth_getBlockTransactionCountByNumber", "latest"); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "eth_getBlockTransactionCountByNumber", "latest"); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
@@ -1,18 +1,19 @@
 package eth
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 )
 
 type EthPendingBlockTransactions struct {
-	rpc  *rpc.Client
+	rpc  Caller
 	desc *prometheus.Desc
 }
 
-func NewEthPendingBlockTransactions(rpc *rpc.Client, blockchain string) *EthPendingBlockTransactions {
+func NewEthPendingBlockTransactions(rpc Caller, blockchain string) *EthPendingBlockTransactions {
 	return &EthPendingBlockTransactions{
 		rpc: rpc,
 		desc: prometheus.NewDesc(
@@ -29,8 +30,12 @@ func (collector *EthPendingBlockTransactions) Describe(ch chan<- *prometheus.Des
 }
 
 func (collector *EthPendingBlockTransactions) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthPendingBlockTransactions) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result hexutil.Uint64
-	if err := collector.rpc.Call(&result, "eth_getBlockTransactionCountByNumber", "pending"); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "eth_getBlockTransactionCountByNumber", "pending"); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
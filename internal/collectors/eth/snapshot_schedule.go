@@ -0,0 +1,162 @@
+package eth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	secondsPerDay = 24 * 60 * 60
+
+	blocksSchedule = "blocks"
+	dailySchedule  = "daily"
+)
+
+// snapshotPoint is a block at which balances are snapshotted.
+type snapshotPoint struct {
+	schedule  string
+	block     uint64
+	timestamp uint64
+}
+
+// snapshotSchedule resolves the blocks at which balance snapshots are taken, either every blockInterval blocks or
+// at the first block of each UTC day.
+type snapshotSchedule struct {
+	rpc           Caller
+	blockInterval uint64
+	daily         bool
+	mutex         sync.Mutex
+	// today holds the first block of the latest day seen by latest, so it is only searched once a day.
+	today *dayBlock
+}
+
+// dayBlock is the first block of the UTC day starting at dayStart.
+type dayBlock struct {
+	dayStart uint64
+	block    uint64
+}
+
+func getBlockHeader(ctx context.Context, rpc Caller, number interface{}) (*blockResult, error) {
+	var result *blockResult
+	if err := rpc.CallContext(ctx, &result, "eth_getBlockByNumber", number, false); err != nil {
+		return nil, errors.Wrapf(err, "failed to get block %v", number)
+	}
+	if result == nil {
+		return nil, errors.Errorf("block %v not found", number)
+	}
+	return result, nil
+}
+
+// firstBlockSince returns the first block in [lo, hi] with a timestamp at or after ts. The block at hi is assumed to
+// satisfy it.
+func (s *snapshotSchedule) firstBlockSince(ctx context.Context, ts, lo, hi uint64) (uint64, error) {
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		header, err := getBlockHeader(ctx, s.rpc, hexutil.Uint64(mid))
+		if err != nil {
+			return 0, err
+		}
+		if uint64(header.Timestamp) >= ts {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
+
+func (s *snapshotSchedule) point(ctx context.Context, schedule string, block uint64) (snapshotPoint, error) {
+	header, err := getBlockHeader(ctx, s.rpc, hexutil.Uint64(block))
+	if err != nil {
+		return snapshotPoint{}, err
+	}
+	return snapshotPoint{schedule, block, uint64(header.Timestamp)}, nil
+}
+
+// dailyBlock returns the first block of the day starting at dayStart, searching no further back than lo.
+func (s *snapshotSchedule) dailyBlock(ctx context.Context, dayStart, lo, hi uint64) (uint64, error) {
+	block, err := s.firstBlockSince(ctx, dayStart, lo, hi)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to find first block of day %d", dayStart)
+	}
+	return block, nil
+}
+
+// dayStart returns the start of the UTC day daysAgo days before the one of ts, or 0 before the epoch.
+func dayStart(ts, daysAgo uint64) uint64 {
+	start := ts - ts%secondsPerDay
+	if daysAgo*secondsPerDay > start {
+		return 0
+	}
+	return start - daysAgo*secondsPerDay
+}
+
+// latest returns the most recent snapshot points at or before head.
+func (s *snapshotSchedule) latest(ctx context.Context, head *blockResult) ([]snapshotPoint, error) {
+	var points []snapshotPoint
+	if s.blockInterval > 0 {
+		number := uint64(head.Number)
+		p, err := s.point(ctx, blocksSchedule, number-number%s.blockInterval)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	if s.daily {
+		today := dayStart(uint64(head.Timestamp), 0)
+		s.mutex.Lock()
+		cached := s.today
+		s.mutex.Unlock()
+		if cached == nil || cached.dayStart != today {
+			block, err := s.dailyBlock(ctx, today, 0, uint64(head.Number))
+			if err != nil {
+				return nil, err
+			}
+			cached = &dayBlock{today, block}
+			s.mutex.Lock()
+			s.today = cached
+			s.mutex.Unlock()
+		}
+		p, err := s.point(ctx, dailySchedule, cached.block)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// between returns every snapshot point in the block range [from, to].
+func (s *snapshotSchedule) between(ctx context.Context, from, to *blockResult) ([]snapshotPoint, error) {
+	var points []snapshotPoint
+	if s.blockInterval > 0 {
+		first := (uint64(from.Number) + s.blockInterval - 1) / s.blockInterval * s.blockInterval
+		for block := first; block <= uint64(to.Number); block += s.blockInterval {
+			p, err := s.point(ctx, blocksSchedule, block)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, p)
+		}
+	}
+	if s.daily {
+		lo := uint64(from.Number)
+		dayStart := (uint64(from.Timestamp) + secondsPerDay - 1) / secondsPerDay * secondsPerDay
+		for ; dayStart <= uint64(to.Timestamp); dayStart += secondsPerDay {
+			block, err := s.dailyBlock(ctx, dayStart, lo, uint64(to.Number))
+			if err != nil {
+				return nil, err
+			}
+			p, err := s.point(ctx, dailySchedule, block)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, p)
+			lo = block
+		}
+	}
+	return points, nil
+}
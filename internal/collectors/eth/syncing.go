@@ -1,30 +1,85 @@
 package eth
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
 )
 
+// syncRateWindow is how far back block observations are kept to estimate the sync rate.
+const syncRateWindow = 10 * time.Minute
+
+// snapSyncFields are the snap sync progress fields reported by geth while syncing.
+var snapSyncFields = []string{
+	"syncedAccounts",
+	"syncedAccountBytes",
+	"syncedBytecodes",
+	"syncedBytecodeBytes",
+	"syncedStorage",
+	"syncedStorageBytes",
+	"healedTrienodes",
+	"healedTrienodeBytes",
+	"healedBytecodes",
+	"healedBytecodeBytes",
+	"healingTrienodes",
+	"healingBytecode",
+}
+
 type EthSyncing struct {
-	rpc          *rpc.Client
+	rpc          Caller
+	syncingDesc  *prometheus.Desc
 	startingDesc *prometheus.Desc
 	currentDesc  *prometheus.Desc
 	highestDesc  *prometheus.Desc
+	progressDesc *prometheus.Desc
+	etaDesc      *prometheus.Desc
+	pulledDesc   *prometheus.Desc
+	knownDesc    *prometheus.Desc
+	snapDesc     *prometheus.Desc
+	stageDesc    *prometheus.Desc
+
+	now          func() time.Time
+	mutex        sync.Mutex
+	observations []syncObservation
+}
+
+type syncObservation struct {
+	block uint64
+	at    time.Time
 }
 
 type syncingResult struct {
 	StartingBlock hexutil.Uint64
 	CurrentBlock  hexutil.Uint64
 	HighestBlock  hexutil.Uint64
+	// PulledStates and KnownStates are reported by geth during fast and snap sync.
+	PulledStates *hexutil.Uint64
+	KnownStates  *hexutil.Uint64
+	// Stages is reported by Erigon, with the progress of each of its sync stages.
+	Stages []struct {
+		StageName   string         `json:"stage_name"`
+		BlockNumber hexutil.Uint64 `json:"block_number"`
+	}
 }
 
-func NewEthSyncing(rpc *rpc.Client, blockchain string) *EthSyncing {
+func NewEthSyncing(rpc Caller, blockchain string) *EthSyncing {
 	return &EthSyncing{
 		rpc: rpc,
+		now: time.Now,
+		syncingDesc: prometheus.NewDesc(
+			"eth_syncing",
+			"whether the node is syncing",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
 		startingDesc: prometheus.NewDesc(
 			"eth_sync_starting",
 			"block number at which current import started",
@@ -43,45 +98,153 @@ func NewEthSyncing(rpc *rpc.Client, blockchain string) *EthSyncing {
 			nil,
 			map[string]string{constants.BlockchainNameLabel: blockchain},
 		),
+		progressDesc: prometheus.NewDesc(
+			"eth_sync_progress",
+			"ratio of blocks imported since the current import started, 1 when synced",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		etaDesc: prometheus.NewDesc(
+			"eth_sync_eta_seconds",
+			"estimated time to sync, from the observed block import rate",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		pulledDesc: prometheus.NewDesc(
+			"eth_sync_pulled_states",
+			"number of state entries processed",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		knownDesc: prometheus.NewDesc(
+			"eth_sync_known_states",
+			"number of known state entries that still need to be pulled",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		snapDesc: prometheus.NewDesc(
+			"eth_sync_snap",
+			"snap sync progress, by field",
+			[]string{"field"},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		stageDesc: prometheus.NewDesc(
+			"eth_sync_stage_block",
+			"block number reached by each sync stage",
+			[]string{"stage"},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
 	}
 }
 
 func (collector *EthSyncing) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.syncingDesc
 	ch <- collector.startingDesc
 	ch <- collector.currentDesc
 	ch <- collector.highestDesc
+	ch <- collector.progressDesc
+	ch <- collector.etaDesc
+	ch <- collector.pulledDesc
+	ch <- collector.knownDesc
+	ch <- collector.snapDesc
+	ch <- collector.stageDesc
+}
+
+// toSnakeCase turns a camelCase field name into snake_case.
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// observe records the current block and returns the estimated import rate in blocks per second, or 0 if unknown.
+func (collector *EthSyncing) observe(current uint64) float64 {
+	now := collector.now()
+	collector.observations = append(collector.observations, syncObservation{current, now})
+
+	for len(collector.observations) > 2 && now.Sub(collector.observations[1].at) >= syncRateWindow {
+		collector.observations = collector.observations[1:]
+	}
+
+	oldest := collector.observations[0]
+	elapsed := now.Sub(oldest.at).Seconds()
+	if elapsed <= 0 || current <= oldest.block {
+		return 0
+	}
+	return float64(current-oldest.block) / elapsed
 }
 
 func (collector *EthSyncing) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *EthSyncing) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
 	var raw json.RawMessage
-	if err := collector.rpc.Call(&raw, "eth_syncing"); err != nil {
-		ch <- prometheus.NewInvalidMetric(collector.startingDesc, err)
-		ch <- prometheus.NewInvalidMetric(collector.currentDesc, err)
-		ch <- prometheus.NewInvalidMetric(collector.highestDesc, err)
+	if err := collector.rpc.CallContext(ctx, &raw, "eth_syncing"); err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.syncingDesc, err)
 		return
 	}
 
 	var syncing bool
 	if err := json.Unmarshal(raw, &syncing); err == nil {
-		err = errors.New("not syncing")
-		ch <- prometheus.NewInvalidMetric(collector.startingDesc, err)
-		ch <- prometheus.NewInvalidMetric(collector.currentDesc, err)
-		ch <- prometheus.NewInvalidMetric(collector.highestDesc, err)
+		collector.observations = nil
+		ch <- prometheus.MustNewConstMetric(collector.syncingDesc, prometheus.GaugeValue, 0)
+		ch <- prometheus.MustNewConstMetric(collector.progressDesc, prometheus.GaugeValue, 1)
 		return
 	}
 
 	var result *syncingResult
 	if err := json.Unmarshal(raw, &result); err != nil {
-		ch <- prometheus.NewInvalidMetric(collector.startingDesc, err)
-		ch <- prometheus.NewInvalidMetric(collector.currentDesc, err)
-		ch <- prometheus.NewInvalidMetric(collector.highestDesc, err)
+		ch <- prometheus.NewInvalidMetric(collector.syncingDesc, err)
 		return
 	}
 
-	value := float64(result.StartingBlock)
-	ch <- prometheus.MustNewConstMetric(collector.startingDesc, prometheus.GaugeValue, value)
-	value = float64(result.CurrentBlock)
-	ch <- prometheus.MustNewConstMetric(collector.currentDesc, prometheus.GaugeValue, value)
-	value = float64(result.HighestBlock)
-	ch <- prometheus.MustNewConstMetric(collector.highestDesc, prometheus.GaugeValue, value)
+	starting, current, highest := uint64(result.StartingBlock), uint64(result.CurrentBlock), uint64(result.HighestBlock)
+	ch <- prometheus.MustNewConstMetric(collector.syncingDesc, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(collector.startingDesc, prometheus.GaugeValue, float64(starting))
+	ch <- prometheus.MustNewConstMetric(collector.currentDesc, prometheus.GaugeValue, float64(current))
+	ch <- prometheus.MustNewConstMetric(collector.highestDesc, prometheus.GaugeValue, float64(highest))
+
+	progress := 1.0
+	if highest > starting && current < highest {
+		// computed in float64 since a node can report a current block below the starting one after a reorg
+		progress = math.Max(0, (float64(current)-float64(starting))/float64(highest-starting))
+	}
+	ch <- prometheus.MustNewConstMetric(collector.progressDesc, prometheus.GaugeValue, progress)
+
+	if rate := collector.observe(current); rate > 0 && highest > current {
+		ch <- prometheus.MustNewConstMetric(collector.etaDesc, prometheus.GaugeValue, float64(highest-current)/rate)
+	}
+
+	if result.PulledStates != nil {
+		ch <- prometheus.MustNewConstMetric(collector.pulledDesc, prometheus.GaugeValue, float64(*result.PulledStates))
+	}
+	if result.KnownStates != nil {
+		ch <- prometheus.MustNewConstMetric(collector.knownDesc, prometheus.GaugeValue, float64(*result.KnownStates))
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err == nil {
+		for _, field := range snapSyncFields {
+			var value hexutil.Uint64
+			if rawValue, ok := fields[field]; ok && json.Unmarshal(rawValue, &value) == nil {
+				ch <- prometheus.MustNewConstMetric(collector.snapDesc, prometheus.GaugeValue, float64(value), toSnakeCase(field))
+			}
+		}
+	}
+
+	for _, stage := range result.Stages {
+		ch <- prometheus.MustNewConstMetric(collector.stageDesc, prometheus.GaugeValue, float64(stage.BlockNumber), stage.StageName)
+	}
 }
@@ -2,10 +2,12 @@ package eth
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
@@ -19,13 +21,13 @@ func TestEthSyncingCollectError(t *testing.T) {
 	}
 
 	collector := NewEthSyncing(rpc, mockBlockchainName)
-	ch := make(chan prometheus.Metric, 3)
+	ch := make(chan prometheus.Metric, 1)
 
 	collector.Collect(ch)
 	close(ch)
 
-	if got := len(ch); got != 3 {
-		t.Fatalf("got %v, want 3", got)
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %v, want 1", got)
 	}
 
 	var metric dto.Metric
@@ -55,23 +57,23 @@ func TestEthSyncingCollectNotSyncing(t *testing.T) {
 	}
 
 	collector := NewEthSyncing(rpc, mockBlockchainName)
-	ch := make(chan prometheus.Metric, 3)
+	ch := make(chan prometheus.Metric, 2)
 
 	collector.Collect(ch)
 	close(ch)
 
-	if got := len(ch); got != 3 {
-		t.Fatalf("got %v, want 3", got)
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
 	}
 
-	var metric dto.Metric
-	for result := range ch {
-		err := result.Write(&metric)
-		if err == nil {
-			t.Fatalf("expected invalid metric, got %#v", metric)
+	// not syncing, and fully synced
+	for _, want := range []float64{0, 1} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
 		}
-		if err.Error() != "not syncing" {
-			t.Fatalf("unexpected error %#v", err)
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
 		}
 	}
 }
@@ -91,13 +93,13 @@ func TestEthSyncingCollectUnmarshalError(t *testing.T) {
 	}
 
 	collector := NewEthSyncing(rpc, mockBlockchainName)
-	ch := make(chan prometheus.Metric, 3)
+	ch := make(chan prometheus.Metric, 1)
 
 	collector.Collect(ch)
 	close(ch)
 
-	if got := len(ch); got != 3 {
-		t.Fatalf("got %v, want 3", got)
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %v, want 1", got)
 	}
 
 	var metric dto.Metric
@@ -127,13 +129,13 @@ func TestEthSyncingCollect(t *testing.T) {
 	}
 
 	collector := NewEthSyncing(rpc, mockBlockchainName)
-	ch := make(chan prometheus.Metric, 3)
+	ch := make(chan prometheus.Metric, 5)
 
 	collector.Collect(ch)
 	close(ch)
 
-	if got := len(ch); got != 3 {
-		t.Fatalf("got %v, want 3", got)
+	if got := len(ch); got != 5 {
+		t.Fatalf("got %v, want 5", got)
 	}
 
 	var (
@@ -141,6 +143,14 @@ func TestEthSyncingCollect(t *testing.T) {
 		result prometheus.Metric
 	)
 
+	result = <-ch
+	if err := result.Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := *metric.Gauge.Value; got != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+
 	result = <-ch
 	if err := result.Write(&metric); err != nil {
 		t.Fatalf("expected metric, got %#v", err)
@@ -173,4 +183,160 @@ func TestEthSyncingCollect(t *testing.T) {
 	if got := *metric.Gauge.Value; got != 1108 {
 		t.Fatalf("got %v, want 1108", got)
 	}
+
+	result = <-ch
+	if err := result.Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := *metric.Gauge.Value; got != 2.0/208 {
+		t.Fatalf("got %v, want %v", got, 2.0/208)
+	}
+}
+
+func TestEthSyncingCollectCurrentBelowStarting(t *testing.T) {
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"result": {"startingBlock": "0x386", "currentBlock": "0x384", "highestBlock": "0x454"}}"`))
+		if err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthSyncing(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 5)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 5 {
+		t.Fatalf("got %v, want 5", got)
+	}
+
+	var (
+		metric dto.Metric
+		result prometheus.Metric
+	)
+	for result = range ch {
+	}
+	if err := result.Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := *metric.Gauge.Value; got != 0 {
+		t.Fatalf("got %v, want 0", got)
+	}
+}
+
+func TestEthSyncingCollectEstimatesTimeToSync(t *testing.T) {
+	current := 0x386
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(fmt.Sprintf(`{"result": {"startingBlock": "0x384", "currentBlock": "0x%x", "highestBlock": "0x454"}}"`, current)))
+		if err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	now := time.Unix(1646870400, 0)
+	collector := NewEthSyncing(rpc, mockBlockchainName)
+	collector.now = func() time.Time { return now }
+
+	ch := make(chan prometheus.Metric, 6)
+	collector.Collect(ch)
+	if got := len(ch); got != 5 {
+		t.Fatalf("got %v, want 5 without a sync rate", got)
+	}
+	for len(ch) > 0 {
+		<-ch
+	}
+
+	// 100 blocks in 50 seconds leaves 106 blocks, or 53 seconds, to sync
+	current += 100
+	now = now.Add(50 * time.Second)
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 6 {
+		t.Fatalf("got %v, want 6", got)
+	}
+
+	// skip syncing, block numbers and progress
+	for i := 0; i < 5; i++ {
+		<-ch
+	}
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := *metric.Gauge.Value; got != 53 {
+		t.Fatalf("got %v, want 53", got)
+	}
+}
+
+func TestEthSyncingCollectExtendedFields(t *testing.T) {
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"result": {
+			"startingBlock": "0x0", "currentBlock": "0x10", "highestBlock": "0x20",
+			"pulledStates": "0x64", "knownStates": "0xc8",
+			"syncedAccounts": "0x3e8", "healedTrienodes": "0x5",
+			"stages": [{"stage_name": "Headers", "block_number": "0x20"}, {"stage_name": "Execution", "block_number": "0x10"}]
+		}}`))
+		if err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewEthSyncing(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 11)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 11 {
+		t.Fatalf("got %v, want 11", got)
+	}
+
+	// skip syncing, block numbers and progress
+	for i := 0; i < 5; i++ {
+		<-ch
+	}
+
+	expected := []struct {
+		label string
+		value float64
+	}{
+		{"", 100},
+		{"", 200},
+		{"synced_accounts", 1000},
+		{"healed_trienodes", 5},
+		{"Headers", 32},
+		{"Execution", 16},
+	}
+	for _, want := range expected {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if want.label != "" && metric.Label[1].GetValue() != want.label {
+			t.Fatalf("got label %v, want %v", metric.Label[1].GetValue(), want.label)
+		}
+		if got := *metric.Gauge.Value; got != want.value {
+			t.Fatalf("got %v, want %v", got, want.value)
+		}
+	}
 }
@@ -0,0 +1,73 @@
+package eth
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+const thresholdLabel = "threshold"
+
+type walletThreshold struct {
+	name      string
+	threshold string
+	value     float64
+}
+
+type EthWalletBalanceThreshold struct {
+	unit       units.Unit
+	mutex      sync.RWMutex
+	thresholds []walletThreshold
+	desc       *prometheus.Desc
+}
+
+func newWalletThresholds(wallets []config.WalletTarget, unit units.Unit) []walletThreshold {
+	var thresholds []walletThreshold
+	for _, w := range wallets {
+		if w.MinBalance != nil {
+			thresholds = append(thresholds, walletThreshold{w.Name, "min", unit.FromEther(*w.MinBalance)})
+		}
+		if w.MaxBalance != nil {
+			thresholds = append(thresholds, walletThreshold{w.Name, "max", unit.FromEther(*w.MaxBalance)})
+		}
+	}
+	return thresholds
+}
+
+func NewEthWalletBalanceThreshold(wallets []config.WalletTarget, unit units.Unit, blockchain string) *EthWalletBalanceThreshold {
+	return &EthWalletBalanceThreshold{
+		unit:       unit,
+		thresholds: newWalletThresholds(wallets, unit),
+		desc: prometheus.NewDesc(
+			"eth_wallet_balance_threshold",
+			"configured wallet balance threshold in "+string(unit),
+			[]string{constants.NameLabel, thresholdLabel},
+			map[string]string{
+				constants.BlockchainNameLabel: blockchain,
+			},
+		),
+	}
+}
+
+func (collector *EthWalletBalanceThreshold) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+}
+
+// SetWallets replaces the wallets whose thresholds are exported.
+func (collector *EthWalletBalanceThreshold) SetWallets(wallets []config.WalletTarget) {
+	thresholds := newWalletThresholds(wallets, collector.unit)
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.thresholds = thresholds
+}
+
+func (collector *EthWalletBalanceThreshold) Collect(ch chan<- prometheus.Metric) {
+	collector.mutex.RLock()
+	defer collector.mutex.RUnlock()
+	for _, t := range collector.thresholds {
+		ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, t.value, t.name, t.threshold)
+	}
+}
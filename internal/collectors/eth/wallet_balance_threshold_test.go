@@ -0,0 +1,75 @@
+package eth
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+func TestEthWalletBalanceThresholdCollect(t *testing.T) {
+	min, max := 0.5, 2.0
+	collector := NewEthWalletBalanceThreshold([]config.WalletTarget{
+		{Addr: mockWalletAddress, Name: mockWalletName, MinBalance: &min, MaxBalance: &max},
+		{Addr: mockWallet2Address, Name: mockWallet2Name},
+	}, units.Wei, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 2)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+
+	expected := map[string]float64{"min": 5e17, "max": 2e18}
+	var metric dto.Metric
+	for result := range ch {
+		if err := result.Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := len(metric.Label); got != 3 {
+			t.Fatalf("expected 3 labels, got %d", got)
+		}
+		var threshold string
+		for _, l := range metric.Label {
+			if l.GetName() == thresholdLabel {
+				threshold = l.GetValue()
+			}
+		}
+		if got := *metric.Gauge.Value; got != expected[threshold] {
+			t.Fatalf("got %v, want %v for %s threshold", got, expected[threshold], threshold)
+		}
+	}
+}
+
+func TestEthWalletBalanceThresholdSetWallets(t *testing.T) {
+	min, max := 0.5, 2.0
+	collector := NewEthWalletBalanceThreshold([]config.WalletTarget{
+		{Addr: mockWalletAddress, Name: mockWalletName, MinBalance: &min, MaxBalance: &max},
+	}, units.Ether, mockBlockchainName)
+	collector.SetWallets([]config.WalletTarget{
+		{Addr: mockWallet2Address, Name: mockWallet2Name, MinBalance: &max},
+	})
+	ch := make(chan prometheus.Metric, 2)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got := metric.Label[1].GetValue(); got != mockWallet2Name {
+		t.Fatalf("got %v, want %v", got, mockWallet2Name)
+	}
+	if got := *metric.Gauge.Value; got != max {
+		t.Fatalf("got %v, want %v", got, max)
+	}
+}
@@ -0,0 +1,127 @@
+// Package instrumented wraps collectors to export how their scrapes went.
+package instrumented
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/scrape"
+)
+
+// maxErrorLength truncates the error label of the last error info metric.
+const maxErrorLength = 256
+
+// Collector exports the duration and success of the last scrape of a collector, along with its error when it failed.
+// A scrape fails when the collector sends an invalid metric, which is still passed on, or when it doesn't finish
+// before the scrape context is done, passing on the metrics sent so far.
+type Collector struct {
+	name      string
+	collector prometheus.Collector
+	// timeout bounds the scrapes of the collector when set.
+	timeout time.Duration
+
+	durationDesc  *prometheus.Desc
+	successDesc   *prometheus.Desc
+	lastErrorDesc *prometheus.Desc
+}
+
+// NewCollector wraps collector, exporting its scrape metrics with the given collector name. Scrapes are cut short
+// after timeout, unless it is zero.
+func NewCollector(name string, collector prometheus.Collector, timeout time.Duration, blockchain string) *Collector {
+	constLabels := prometheus.Labels{
+		constants.BlockchainNameLabel: blockchain,
+		"collector":                   name,
+	}
+	return &Collector{
+		name:      name,
+		collector: collector,
+		timeout:   timeout,
+		durationDesc: prometheus.NewDesc(
+			"ethexporter_collector_duration_seconds",
+			"Duration of the last scrape of the collector",
+			nil,
+			constLabels,
+		),
+		successDesc: prometheus.NewDesc(
+			"ethexporter_collector_success",
+			"Whether the last scrape of the collector succeeded",
+			nil,
+			constLabels,
+		),
+		lastErrorDesc: prometheus.NewDesc(
+			"ethexporter_collector_last_error_info",
+			"Error of the last scrape of the collector, only exported when it failed",
+			[]string{"error"},
+			constLabels,
+		),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+	ch <- c.durationDesc
+	ch <- c.successDesc
+	ch <- c.lastErrorDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *Collector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	start := time.Now()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		scrape.Collect(ctx, c.collector, metrics)
+		close(metrics)
+	}()
+	err := forward(ctx, metrics, ch)
+
+	ch <- prometheus.MustNewConstMetric(c.durationDesc, prometheus.GaugeValue, time.Since(start).Seconds())
+	if err != nil {
+		message := err.Error()
+		if len(message) > maxErrorLength {
+			// label values must be valid UTF-8
+			message = strings.ToValidUTF8(message[:maxErrorLength], "")
+		}
+		ch <- prometheus.MustNewConstMetric(c.successDesc, prometheus.GaugeValue, 0)
+		ch <- prometheus.MustNewConstMetric(c.lastErrorDesc, prometheus.GaugeValue, 1, message)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.successDesc, prometheus.GaugeValue, 1)
+}
+
+// forward passes on metrics to ch until metrics is closed or ctx is done, and returns the error of the first invalid
+// metric, or the one of ctx when it is done first. Metrics sent after ctx is done are dropped.
+func forward(ctx context.Context, metrics <-chan prometheus.Metric, ch chan<- prometheus.Metric) error {
+	var firstErr error
+	for {
+		select {
+		case metric, ok := <-metrics:
+			if !ok {
+				return firstErr
+			}
+			if firstErr == nil {
+				firstErr = metric.Write(&dto.Metric{})
+			}
+			ch <- metric
+		case <-ctx.Done():
+			go func() {
+				for range metrics {
+				}
+			}()
+			return errors.Wrap(ctx.Err(), "scrape cut short")
+		}
+	}
+}
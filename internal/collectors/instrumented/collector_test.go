@@ -0,0 +1,144 @@
+package instrumented
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const mockBlockchainName = "test_blockchain"
+
+// mockCollector sends a gauge, or an invalid metric when err is set.
+type mockCollector struct {
+	desc *prometheus.Desc
+	err  error
+}
+
+func newMockCollector(err error) *mockCollector {
+	return &mockCollector{
+		desc: prometheus.NewDesc("mock_metric", "Mock metric", nil, nil),
+		err:  err,
+	}
+}
+
+func (c *mockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *mockCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, c.err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1)
+}
+
+// hangingCollector sends a gauge, then hangs until its scrape context is done.
+type hangingCollector struct {
+	*mockCollector
+}
+
+func (c hangingCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.mockCollector.Collect(ch)
+	<-ctx.Done()
+}
+
+// gather registers collector on a pedantic registry and returns the gathered families by name.
+func gather(t *testing.T, collector prometheus.Collector) (map[string]*dto.MetricFamily, error) {
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("unexpected registration error: %v", err)
+	}
+	families, err := registry.Gather()
+	byName := map[string]*dto.MetricFamily{}
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName, err
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestCollectorCollect(t *testing.T) {
+	families, err := gather(t, NewCollector("mock", newMockCollector(nil), 0, mockBlockchainName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := families["mock_metric"]; !ok {
+		t.Fatalf("expected the metrics of the wrapped collector")
+	}
+	success := families["ethexporter_collector_success"].GetMetric()[0]
+	if got := success.GetGauge().GetValue(); got != 1 {
+		t.Fatalf("got success %v, want 1", got)
+	}
+	if got := labelValue(success, "collector"); got != "mock" {
+		t.Fatalf("got collector %q, want mock", got)
+	}
+	if _, ok := families["ethexporter_collector_duration_seconds"]; !ok {
+		t.Fatalf("expected a duration metric")
+	}
+	if _, ok := families["ethexporter_collector_last_error_info"]; ok {
+		t.Fatalf("expected no last error metric")
+	}
+}
+
+func TestCollectorCollectError(t *testing.T) {
+	families, err := gather(t, NewCollector("mock", newMockCollector(errors.New(strings.Repeat("é", maxErrorLength))), 0, mockBlockchainName))
+	if err == nil {
+		t.Fatalf("expected the invalid metric to be passed on")
+	}
+
+	if got := families["ethexporter_collector_success"].GetMetric()[0].GetGauge().GetValue(); got != 0 {
+		t.Fatalf("got success %v, want 0", got)
+	}
+	lastError := families["ethexporter_collector_last_error_info"].GetMetric()[0]
+	if got := labelValue(lastError, "error"); got != strings.Repeat("é", maxErrorLength/2) {
+		t.Fatalf("got error %q, want it truncated to %d bytes", got, maxErrorLength)
+	}
+}
+
+func TestCollectorCollectTimeout(t *testing.T) {
+	families, err := gather(t, NewCollector("mock", hangingCollector{newMockCollector(nil)}, 10*time.Millisecond, mockBlockchainName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := families["mock_metric"]; !ok {
+		t.Fatalf("expected the metrics sent before the timeout")
+	}
+	if got := families["ethexporter_collector_success"].GetMetric()[0].GetGauge().GetValue(); got != 0 {
+		t.Fatalf("got success %v, want 0", got)
+	}
+	lastError := families["ethexporter_collector_last_error_info"].GetMetric()[0]
+	if got, want := labelValue(lastError, "error"), "scrape cut short: context deadline exceeded"; got != want {
+		t.Fatalf("got error %q, want %q", got, want)
+	}
+}
+
+func TestCollectorsRegisterTogether(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	first := NewCollector("first", newMockCollector(nil), 0, mockBlockchainName)
+	second := NewCollector("second", &mockCollector{desc: prometheus.NewDesc("other_metric", "Other metric", nil, nil)}, 0, mockBlockchainName)
+	if err := registry.Register(first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !registry.Unregister(second) {
+		t.Fatalf("expected the wrapped collector to be unregistered")
+	}
+}
@@ -0,0 +1,215 @@
+package l2
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/rpcerr"
+)
+
+// nodeInterfaceAddress is the address of Arbitrum's NodeInterface virtual contract, only reachable through eth_call.
+var nodeInterfaceAddress = common.HexToAddress("0x00000000000000000000000000000000000000C8")
+
+const nodeInterfaceABI = `[{"inputs":[{"internalType":"uint64","name":"blockNum","type":"uint64"}],"name":"findBatchContainingBlock","outputs":[{"internalType":"uint64","name":"batch","type":"uint64"}],"stateMutability":"view","type":"function"}]`
+
+// batchPostingWindow is how many blocks behind the head are searched for the last block posted to L1.
+const batchPostingWindow = 1 << 16
+
+// ArbitrumBatchPosting exports how far the sequencer's batch poster is behind the head, by searching for the latest
+// block NodeInterface reports as part of a batch posted to L1. The search starts from the block found on the previous
+// scrape, so that once it was found a scrape only checks the blocks posted since.
+type ArbitrumBatchPosting struct {
+	rpc         *rpc.Client
+	abi         abi.ABI
+	blocksDesc  *prometheus.Desc
+	secondsDesc *prometheus.Desc
+	batchDesc   *prometheus.Desc
+
+	mutex      sync.Mutex
+	found      bool
+	lastPosted uint64
+	lastBatch  uint64
+}
+
+func NewArbitrumBatchPosting(rpc *rpc.Client, blockchain string) *ArbitrumBatchPosting {
+	parsed, err := abi.JSON(strings.NewReader(nodeInterfaceABI))
+	if err != nil {
+		panic(err)
+	}
+	return &ArbitrumBatchPosting{
+		rpc: rpc,
+		abi: parsed,
+		blocksDesc: prometheus.NewDesc(
+			"arbitrum_batch_posting_lag_blocks",
+			"number of blocks not yet posted to L1 in a batch",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		secondsDesc: prometheus.NewDesc(
+			"arbitrum_batch_posting_lag_seconds",
+			"age of the oldest block not yet posted to L1 in a batch",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		batchDesc: prometheus.NewDesc(
+			"arbitrum_latest_batch",
+			"number of the latest batch posted to L1",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *ArbitrumBatchPosting) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.blocksDesc
+	ch <- collector.secondsDesc
+	ch <- collector.batchDesc
+}
+
+func (collector *ArbitrumBatchPosting) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *ArbitrumBatchPosting) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if err := collector.collect(ctx, ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.blocksDesc, err)
+		ch <- prometheus.NewInvalidMetric(collector.secondsDesc, err)
+		ch <- prometheus.NewInvalidMetric(collector.batchDesc, err)
+	}
+}
+
+func (collector *ArbitrumBatchPosting) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	head, err := getBlock(ctx, collector.rpc, "latest")
+	if err != nil {
+		return err
+	}
+
+	low, high := uint64(0), uint64(head.Number)
+	if high > batchPostingWindow {
+		low = high - batchPostingWindow
+	}
+
+	collector.mutex.Lock()
+	found, lastPosted, lastBatch := collector.found, collector.lastPosted, collector.lastBatch
+	collector.mutex.Unlock()
+
+	var batch uint64
+	if found && lastPosted >= low && lastPosted <= high {
+		// posted blocks stay posted, so probe forward from the last one with doubling steps, which costs a single
+		// call when nothing or a single batch was posted since
+		low, batch = lastPosted, lastBatch
+		for step := uint64(1); low < high; step *= 2 {
+			next := lastPosted + step
+			if next > high {
+				next = high
+			}
+			nextBatch, nextPosted, err := collector.findBatch(ctx, next)
+			if err != nil {
+				return err
+			}
+			if !nextPosted {
+				high = next - 1
+				break
+			}
+			low, batch = next, nextBatch
+		}
+	} else {
+		var posted bool
+		var err error
+		batch, posted, err = collector.findBatch(ctx, low)
+		if err != nil {
+			return err
+		}
+		if !posted {
+			// nothing in the window was posted, so the lag is at least the window
+			return collector.collectLag(ctx, ch, head, low, 0, false)
+		}
+	}
+
+	// binary search for the last posted block, low always being posted
+	for low < high {
+		mid := low + (high-low+1)/2
+		midBatch, midPosted, err := collector.findBatch(ctx, mid)
+		if err != nil {
+			return err
+		}
+		if midPosted {
+			low, batch = mid, midBatch
+		} else {
+			high = mid - 1
+		}
+	}
+
+	collector.mutex.Lock()
+	collector.found, collector.lastPosted, collector.lastBatch = true, low, batch
+	collector.mutex.Unlock()
+	return collector.collectLag(ctx, ch, head, low+1, batch, true)
+}
+
+// collectLag exports the lag given the first block not yet posted.
+func (collector *ArbitrumBatchPosting) collectLag(ctx context.Context, ch chan<- prometheus.Metric, head *l2Block, unposted, batch uint64, posted bool) error {
+	lagBlocks, lagSeconds := uint64(0), uint64(0)
+	if unposted <= uint64(head.Number) {
+		block, err := getBlock(ctx, collector.rpc, hexutil.Uint64(unposted))
+		if err != nil {
+			return err
+		}
+		lagBlocks = uint64(head.Number) - unposted + 1
+		if head.Timestamp > block.Timestamp {
+			lagSeconds = uint64(head.Timestamp - block.Timestamp)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.blocksDesc, prometheus.GaugeValue, float64(lagBlocks))
+	ch <- prometheus.MustNewConstMetric(collector.secondsDesc, prometheus.GaugeValue, float64(lagSeconds))
+	if posted {
+		ch <- prometheus.MustNewConstMetric(collector.batchDesc, prometheus.GaugeValue, float64(batch))
+	}
+	return nil
+}
+
+// findBatch returns the batch containing block, and false if the block was not posted yet, which NodeInterface
+// reports by reverting.
+func (collector *ArbitrumBatchPosting) findBatch(ctx context.Context, block uint64) (uint64, bool, error) {
+	data, err := collector.abi.Pack("findBatchContainingBlock", block)
+	if err != nil {
+		return 0, false, err
+	}
+	msg := map[string]interface{}{
+		"to":   nodeInterfaceAddress,
+		"data": hexutil.Bytes(data),
+	}
+	var result hexutil.Bytes
+	if err := collector.rpc.CallContext(ctx, &result, "eth_call", msg, "latest"); err != nil {
+		if isExecutionReverted(err) {
+			return 0, false, nil
+		}
+		return 0, false, errors.Wrapf(err, "failed to find batch of block %d", block)
+	}
+
+	out, err := collector.abi.Unpack("findBatchContainingBlock", result)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to unpack batch")
+	}
+	return *abi.ConvertType(out[0], new(uint64)).(*uint64), true, nil
+}
+
+// isExecutionReverted returns whether err is a call reverting, rather than failing to be made.
+func isExecutionReverted(err error) bool {
+	code, ok := rpcerr.Code(err)
+	switch {
+	case !ok:
+		return false
+	case code == rpcerr.ExecutionRevertedCode:
+		return true
+	}
+	return code == rpcerr.ServerErrorCode && strings.Contains(err.Error(), "execution reverted")
+}
@@ -0,0 +1,223 @@
+package l2
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// mockArbitrumChain is a chain whose blocks up to lastPosted were posted in batches of 100 blocks.
+type mockArbitrumChain struct {
+	mutex      sync.Mutex
+	head       uint64
+	lastPosted uint64
+	calls      int
+}
+
+func (chain *mockArbitrumChain) advance(head, lastPosted uint64) {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+	chain.head, chain.lastPosted, chain.calls = head, lastPosted, 0
+}
+
+// ethCalls returns the eth_calls made since the chain last advanced.
+func (chain *mockArbitrumChain) ethCalls() int {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+	return chain.calls
+}
+
+// newMockArbitrumServer serves chain, whose blocks have their number as timestamp.
+func newMockArbitrumServer(t *testing.T, chain *mockArbitrumChain) *httptest.Server {
+	return newMockRPCServer(t, map[string]mockHandler{
+		"eth_getBlockByNumber": func(params []json.RawMessage) (string, string) {
+			chain.mutex.Lock()
+			defer chain.mutex.Unlock()
+			number := chain.head
+			if string(params[0]) != `"latest"` {
+				var n hexutil.Uint64
+				if err := json.Unmarshal(params[0], &n); err != nil {
+					t.Fatalf("could not decode block number: %#v", err)
+				}
+				number = uint64(n)
+			}
+			return fmt.Sprintf(`{"number": "%#x", "timestamp": "%#x", "transactions": []}`, number, number), ""
+		},
+		"eth_call": func(params []json.RawMessage) (string, string) {
+			var msg struct {
+				To   common.Address
+				Data hexutil.Bytes
+			}
+			if err := json.Unmarshal(params[0], &msg); err != nil {
+				t.Fatalf("could not decode call: %#v", err)
+			}
+			if msg.To != nodeInterfaceAddress {
+				t.Fatalf("got call to %v, want %v", msg.To, nodeInterfaceAddress)
+			}
+			chain.mutex.Lock()
+			defer chain.mutex.Unlock()
+			chain.calls++
+			block := new(big.Int).SetBytes(msg.Data[4:]).Uint64()
+			if block > chain.lastPosted {
+				return "", "execution reverted: requested block is after latest on-chain block published in batch"
+			}
+			return fmt.Sprintf(`"%s"`, hexutil.Encode(common.LeftPadBytes(new(big.Int).SetUint64(block/100).Bytes(), 32))), ""
+		},
+	})
+}
+
+func TestArbitrumBatchPostingCollect(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		head       uint64
+		lastPosted uint64
+		want       []float64
+	}{
+		{"lagging", 100000, 99990, []float64{10, 9, 999}},
+		{"caught up", 100000, 100000, []float64{0, 0, 1000}},
+		{"short chain", 500, 250, []float64{250, 249, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rpcServer := newMockArbitrumServer(t, &mockArbitrumChain{head: tc.head, lastPosted: tc.lastPosted})
+			defer rpcServer.Close()
+
+			rpc, err := rpc.DialHTTP(rpcServer.URL)
+			if err != nil {
+				t.Fatalf("rpc connection error: %#v", err)
+			}
+
+			collector := NewArbitrumBatchPosting(rpc, mockBlockchainName)
+			ch := make(chan prometheus.Metric, 3)
+
+			collector.Collect(ch)
+			close(ch)
+
+			if got := len(ch); got != 3 {
+				t.Fatalf("got %v, want 3", got)
+			}
+			for _, want := range tc.want {
+				var metric dto.Metric
+				if err := (<-ch).Write(&metric); err != nil {
+					t.Fatalf("expected metric, got %#v", err)
+				}
+				if got := *metric.Gauge.Value; got != want {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestArbitrumBatchPostingCollectFromLastPosted(t *testing.T) {
+	chain := &mockArbitrumChain{head: 100000, lastPosted: 99990}
+	rpcServer := newMockArbitrumServer(t, chain)
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewArbitrumBatchPosting(rpc, mockBlockchainName)
+	for _, tc := range []struct {
+		head       uint64
+		lastPosted uint64
+		wantCalls  int
+		want       []float64
+	}{
+		{100000, 99990, 17, []float64{10, 9, 999}},
+		{100005, 99990, 1, []float64{15, 14, 999}},
+		{100010, 99991, 2, []float64{19, 18, 999}},
+		{100020, 100015, 10, []float64{5, 4, 1000}},
+	} {
+		chain.advance(tc.head, tc.lastPosted)
+		ch := make(chan prometheus.Metric, 3)
+
+		collector.Collect(ch)
+		close(ch)
+
+		if got := chain.ethCalls(); got != tc.wantCalls {
+			t.Fatalf("got %v calls, want %v", got, tc.wantCalls)
+		}
+		if got := len(ch); got != 3 {
+			t.Fatalf("got %v, want 3", got)
+		}
+		for _, want := range tc.want {
+			var metric dto.Metric
+			if err := (<-ch).Write(&metric); err != nil {
+				t.Fatalf("expected metric, got %#v", err)
+			}
+			if got := *metric.Gauge.Value; got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	}
+}
+
+func TestArbitrumBatchPostingCollectNothingPosted(t *testing.T) {
+	rpcServer := newMockArbitrumServer(t, &mockArbitrumChain{head: 100000})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewArbitrumBatchPosting(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	// without a posted block in the window the lag is reported as the whole window, and there is no latest batch
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+	var metric dto.Metric
+	if err := (<-ch).Write(&metric); err != nil {
+		t.Fatalf("expected metric, got %#v", err)
+	}
+	if got, want := *metric.Gauge.Value, float64(batchPostingWindow+1); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestArbitrumBatchPostingCollectCallError(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]mockHandler{
+		"eth_getBlockByNumber": func(params []json.RawMessage) (string, string) {
+			return `{"number": "0x186a0", "timestamp": "0x186a0", "transactions": []}`, ""
+		},
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewArbitrumBatchPosting(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	// only reverted calls mean a block was not posted, other errors fail the scrape
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %v, want 3", got)
+	}
+	for result := range ch {
+		var metric dto.Metric
+		if err := result.Write(&metric); err == nil {
+			t.Fatalf("expected invalid metric, got %#v", metric)
+		}
+	}
+}
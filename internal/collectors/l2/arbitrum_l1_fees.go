@@ -0,0 +1,72 @@
+package l2
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+// ArbitrumL1Fees exports the L1 gas used by the transactions of the latest block, from the fields Arbitrum adds to
+// receipts, and the L1 block number the latest block was built on.
+type ArbitrumL1Fees struct {
+	rpc         *rpc.Client
+	gasUsedDesc *prometheus.Desc
+	l1BlockDesc *prometheus.Desc
+}
+
+func NewArbitrumL1Fees(rpc *rpc.Client, blockchain string) *ArbitrumL1Fees {
+	return &ArbitrumL1Fees{
+		rpc: rpc,
+		gasUsedDesc: prometheus.NewDesc(
+			"arbitrum_l1_gas_used",
+			"total gas used for L1 by the transactions of the latest block",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		l1BlockDesc: prometheus.NewDesc(
+			"arbitrum_l1_block_number",
+			"number of the L1 block the latest block was built on",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *ArbitrumL1Fees) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.gasUsedDesc
+	ch <- collector.l1BlockDesc
+}
+
+func (collector *ArbitrumL1Fees) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *ArbitrumL1Fees) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	block, err := getBlock(ctx, collector.rpc, "latest")
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.gasUsedDesc, err)
+		ch <- prometheus.NewInvalidMetric(collector.l1BlockDesc, err)
+		return
+	}
+
+	if block.L1BlockNumber != nil {
+		ch <- prometheus.MustNewConstMetric(collector.l1BlockDesc, prometheus.GaugeValue, float64(*block.L1BlockNumber))
+	}
+
+	receipts, err := getReceipts(ctx, collector.rpc, block)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.gasUsedDesc, err)
+		return
+	}
+	gasUsed := new(big.Int)
+	for _, receipt := range receipts {
+		if receipt.GasUsedForL1 != nil {
+			gasUsed.Add(gasUsed, receipt.GasUsedForL1.ToInt())
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(collector.gasUsedDesc, prometheus.GaugeValue, units.Wei.FromWei(gasUsed))
+}
@@ -0,0 +1,50 @@
+package l2
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestArbitrumL1FeesCollect(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]mockHandler{
+		"eth_getBlockByNumber": staticResult(`{
+			"number": "0x10",
+			"timestamp": "0x64",
+			"l1BlockNumber": "0xf4240",
+			"transactions": [
+				"0x0000000000000000000000000000000000000000000000000000000000000001",
+				"0x0000000000000000000000000000000000000000000000000000000000000002"
+			]
+		}`),
+		"eth_getTransactionReceipt": staticResult(`{"type": "0x2", "gasUsedForL1": "0x100"}`),
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewArbitrumL1Fees(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 2)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+
+	for _, want := range []float64{1000000, 512} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package l2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const mockBlockchainName = "test_blockchain"
+
+type mockRequest struct {
+	ID     json.RawMessage
+	Method string
+	Params []json.RawMessage
+}
+
+// mockHandler returns the raw result for a request, or a non-empty error message to answer with a JSON-RPC error.
+type mockHandler func(params []json.RawMessage) (result string, errMessage string)
+
+// newMockRPCServer dispatches single and batch JSON-RPC requests to the handler registered for their method, and
+// answers any other method with a method not found error.
+func newMockRPCServer(t *testing.T, handlers map[string]mockHandler) *httptest.Server {
+	respond := func(req mockRequest) string {
+		handler, ok := handlers[req.Method]
+		if !ok {
+			return fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": -32601, "message": "the method %s does not exist/is not available"}}`, req.ID, req.Method)
+		}
+		result, errMessage := handler(req.Params)
+		if errMessage != "" {
+			return fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": 3, "message": %q}}`, req.ID, errMessage)
+		}
+		return fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": %s}`, req.ID, result)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("could not decode request: %#v", err)
+		}
+
+		var response string
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			var reqs []mockRequest
+			if err := json.Unmarshal(body, &reqs); err != nil {
+				t.Fatalf("could not decode batch request: %#v", err)
+			}
+			responses := make([]string, len(reqs))
+			for i, req := range reqs {
+				responses[i] = respond(req)
+			}
+			response = "[" + strings.Join(responses, ",") + "]"
+		} else {
+			var req mockRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Fatalf("could not decode request: %#v", err)
+			}
+			response = respond(req)
+		}
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+}
+
+// staticResult answers every request with the same raw result.
+func staticResult(result string) mockHandler {
+	return func([]json.RawMessage) (string, string) {
+		return result, ""
+	}
+}
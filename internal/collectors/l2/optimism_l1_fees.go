@@ -0,0 +1,114 @@
+package l2
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+// OptimismL1Fees exports the L1 data fee components paid by the transactions of the latest block, from the fields
+// OP-stack chains add to receipts.
+type OptimismL1Fees struct {
+	rpc           *rpc.Client
+	feeUnit       units.Unit
+	gasPriceUnit  units.Unit
+	feeDesc       *prometheus.Desc
+	gasUsedDesc   *prometheus.Desc
+	gasPriceDesc  *prometheus.Desc
+	feeScalarDesc *prometheus.Desc
+}
+
+func NewOptimismL1Fees(rpc *rpc.Client, feeUnit, gasPriceUnit units.Unit, blockchain string) *OptimismL1Fees {
+	return &OptimismL1Fees{
+		rpc:          rpc,
+		feeUnit:      feeUnit,
+		gasPriceUnit: gasPriceUnit,
+		feeDesc: prometheus.NewDesc(
+			"optimism_l1_fee",
+			"total L1 data fee paid by the transactions of the latest block in "+string(feeUnit),
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		gasUsedDesc: prometheus.NewDesc(
+			"optimism_l1_gas_used",
+			"total L1 gas used by the transactions of the latest block",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		gasPriceDesc: prometheus.NewDesc(
+			"optimism_l1_gas_price",
+			"L1 gas price applied to the latest block in "+string(gasPriceUnit),
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		feeScalarDesc: prometheus.NewDesc(
+			"optimism_l1_fee_scalar",
+			"L1 fee scalar applied to the latest block",
+			nil,
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *OptimismL1Fees) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.feeDesc
+	ch <- collector.gasUsedDesc
+	ch <- collector.gasPriceDesc
+	ch <- collector.feeScalarDesc
+}
+
+func (collector *OptimismL1Fees) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *OptimismL1Fees) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	block, err := getBlock(ctx, collector.rpc, "latest")
+	if err == nil {
+		var receipts []l2Receipt
+		receipts, err = getReceipts(ctx, collector.rpc, block)
+		if err == nil {
+			collector.collectReceipts(ch, receipts)
+			return
+		}
+	}
+	ch <- prometheus.NewInvalidMetric(collector.feeDesc, err)
+	ch <- prometheus.NewInvalidMetric(collector.gasUsedDesc, err)
+	ch <- prometheus.NewInvalidMetric(collector.gasPriceDesc, err)
+	ch <- prometheus.NewInvalidMetric(collector.feeScalarDesc, err)
+}
+
+func (collector *OptimismL1Fees) collectReceipts(ch chan<- prometheus.Metric, receipts []l2Receipt) {
+	fee, gasUsed := new(big.Int), new(big.Int)
+	var gasPrice *big.Int
+	var feeScalar string
+	for _, receipt := range receipts {
+		if receipt.Type == depositTxType || receipt.L1Fee == nil {
+			continue
+		}
+		fee.Add(fee, receipt.L1Fee.ToInt())
+		if receipt.L1GasUsed != nil {
+			gasUsed.Add(gasUsed, receipt.L1GasUsed.ToInt())
+		}
+		if receipt.L1GasPrice != nil {
+			gasPrice = receipt.L1GasPrice.ToInt()
+		}
+		if receipt.L1FeeScalar != "" {
+			feeScalar = receipt.L1FeeScalar
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.feeDesc, prometheus.GaugeValue, collector.feeUnit.FromWei(fee))
+	ch <- prometheus.MustNewConstMetric(collector.gasUsedDesc, prometheus.GaugeValue, units.Wei.FromWei(gasUsed))
+	// gas price and fee scalar are only known when the block has a non-deposit transaction
+	if gasPrice != nil {
+		ch <- prometheus.MustNewConstMetric(collector.gasPriceDesc, prometheus.GaugeValue, collector.gasPriceUnit.FromWei(gasPrice))
+	}
+	if scalar, err := strconv.ParseFloat(feeScalar, 64); err == nil {
+		ch <- prometheus.MustNewConstMetric(collector.feeScalarDesc, prometheus.GaugeValue, scalar)
+	}
+}
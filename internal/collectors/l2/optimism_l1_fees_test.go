@@ -0,0 +1,95 @@
+package l2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+func TestOptimismL1FeesCollect(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]mockHandler{
+		"eth_getBlockByNumber": staticResult(`{
+			"number": "0x10",
+			"timestamp": "0x64",
+			"transactions": [
+				"0x0000000000000000000000000000000000000000000000000000000000000001",
+				"0x0000000000000000000000000000000000000000000000000000000000000002"
+			]
+		}`),
+		"eth_getTransactionReceipt": func(params []json.RawMessage) (string, string) {
+			if string(params[0]) == `"0x0000000000000000000000000000000000000000000000000000000000000001"` {
+				// deposit transaction
+				return `{"type": "0x7e", "l1Fee": "0x0", "l1GasUsed": "0x0"}`, ""
+			}
+			// 0.001 ether fee at a 20 gwei L1 gas price
+			return `{"type": "0x2", "l1Fee": "0x38d7ea4c68000", "l1GasUsed": "0x640", "l1GasPrice": "0x4a817c800", "l1FeeScalar": "0.684"}`, ""
+		},
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewOptimismL1Fees(rpc, units.Ether, units.Gwei, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 4)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 4 {
+		t.Fatalf("got %v, want 4", got)
+	}
+
+	for _, want := range []float64{0.001, 1600, 20, 0.684} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestOptimismL1FeesCollectOnlyDeposits(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]mockHandler{
+		"eth_getBlockByNumber": staticResult(`{
+			"number": "0x10",
+			"timestamp": "0x64",
+			"transactions": ["0x0000000000000000000000000000000000000000000000000000000000000001"]
+		}`),
+		"eth_getTransactionReceipt": staticResult(`{"type": "0x7e"}`),
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewOptimismL1Fees(rpc, units.Ether, units.Gwei, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 4)
+
+	collector.Collect(ch)
+	close(ch)
+
+	// the gas price and fee scalar are unknown without a non-deposit transaction
+	if got := len(ch); got != 2 {
+		t.Fatalf("got %v, want 2", got)
+	}
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != 0 {
+			t.Fatalf("got %v, want 0", got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package l2
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+)
+
+const headLabel = "head"
+
+type blockRef struct {
+	Number    uint64 `json:"number"`
+	Timestamp uint64 `json:"timestamp"`
+}
+
+type l2BlockRef struct {
+	blockRef
+	L1Origin blockRef `json:"l1origin"`
+}
+
+type syncStatus struct {
+	CurrentL1   blockRef   `json:"current_l1"`
+	HeadL1      blockRef   `json:"head_l1"`
+	SafeL1      blockRef   `json:"safe_l1"`
+	FinalizedL1 blockRef   `json:"finalized_l1"`
+	UnsafeL2    l2BlockRef `json:"unsafe_l2"`
+	SafeL2      l2BlockRef `json:"safe_l2"`
+	FinalizedL2 l2BlockRef `json:"finalized_l2"`
+}
+
+// OptimismSyncStatus exports the L1 and L2 heads tracked by an OP-stack rollup node, from optimism_syncStatus.
+type OptimismSyncStatus struct {
+	rollup       *rpc.Client
+	l1Desc       *prometheus.Desc
+	l2Desc       *prometheus.Desc
+	l1OriginDesc *prometheus.Desc
+}
+
+func NewOptimismSyncStatus(rollup *rpc.Client, blockchain string) *OptimismSyncStatus {
+	return &OptimismSyncStatus{
+		rollup: rollup,
+		l1Desc: prometheus.NewDesc(
+			"optimism_l1_block_number",
+			"number of the L1 block tracked by the rollup node, by head",
+			[]string{headLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		l2Desc: prometheus.NewDesc(
+			"optimism_l2_block_number",
+			"number of the unsafe, safe and finalized L2 heads",
+			[]string{headLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+		l1OriginDesc: prometheus.NewDesc(
+			"optimism_l2_l1_origin_block_number",
+			"number of the L1 origin block of the unsafe, safe and finalized L2 heads",
+			[]string{headLabel},
+			map[string]string{constants.BlockchainNameLabel: blockchain},
+		),
+	}
+}
+
+func (collector *OptimismSyncStatus) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.l1Desc
+	ch <- collector.l2Desc
+	ch <- collector.l1OriginDesc
+}
+
+func (collector *OptimismSyncStatus) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *OptimismSyncStatus) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	var status syncStatus
+	if err := collector.rollup.CallContext(ctx, &status, "optimism_syncStatus"); err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.l1Desc, err)
+		ch <- prometheus.NewInvalidMetric(collector.l2Desc, err)
+		ch <- prometheus.NewInvalidMetric(collector.l1OriginDesc, err)
+		return
+	}
+
+	l1Heads := []struct {
+		head string
+		ref  blockRef
+	}{
+		{"current", status.CurrentL1},
+		{"head", status.HeadL1},
+		{"safe", status.SafeL1},
+		{"finalized", status.FinalizedL1},
+	}
+	for _, h := range l1Heads {
+		ch <- prometheus.MustNewConstMetric(collector.l1Desc, prometheus.GaugeValue, float64(h.ref.Number), h.head)
+	}
+
+	l2Heads := []struct {
+		head string
+		ref  l2BlockRef
+	}{
+		{"unsafe", status.UnsafeL2},
+		{"safe", status.SafeL2},
+		{"finalized", status.FinalizedL2},
+	}
+	for _, h := range l2Heads {
+		ch <- prometheus.MustNewConstMetric(collector.l2Desc, prometheus.GaugeValue, float64(h.ref.Number), h.head)
+		ch <- prometheus.MustNewConstMetric(collector.l1OriginDesc, prometheus.GaugeValue, float64(h.ref.L1Origin.Number), h.head)
+	}
+}
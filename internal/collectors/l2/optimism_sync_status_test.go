@@ -0,0 +1,77 @@
+package l2
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestOptimismSyncStatusCollectError(t *testing.T) {
+	rpc, err := rpc.DialHTTP("http://localhost")
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewOptimismSyncStatus(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 3)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %v, want 3", got)
+	}
+	for m := range ch {
+		var metric dto.Metric
+		err := m.Write(&metric)
+		switch err.(type) {
+		case *url.Error:
+		default:
+			t.Fatalf("unexpected error %#v", err)
+		}
+	}
+}
+
+func TestOptimismSyncStatusCollect(t *testing.T) {
+	rpcServer := newMockRPCServer(t, map[string]mockHandler{
+		"optimism_syncStatus": staticResult(`{
+			"current_l1": {"hash": "0x01", "number": 100, "timestamp": 1000},
+			"head_l1": {"hash": "0x02", "number": 101, "timestamp": 1012},
+			"safe_l1": {"hash": "0x03", "number": 90, "timestamp": 880},
+			"finalized_l1": {"hash": "0x04", "number": 60, "timestamp": 520},
+			"unsafe_l2": {"hash": "0x05", "number": 5000, "timestamp": 1010, "l1origin": {"hash": "0x01", "number": 99}},
+			"safe_l2": {"hash": "0x06", "number": 4800, "timestamp": 610, "l1origin": {"hash": "0x03", "number": 89}},
+			"finalized_l2": {"hash": "0x07", "number": 4000, "timestamp": 10, "l1origin": {"hash": "0x04", "number": 59}}
+		}`),
+	})
+	defer rpcServer.Close()
+
+	rpc, err := rpc.DialHTTP(rpcServer.URL)
+	if err != nil {
+		t.Fatalf("rpc connection error: %#v", err)
+	}
+
+	collector := NewOptimismSyncStatus(rpc, mockBlockchainName)
+	ch := make(chan prometheus.Metric, 10)
+
+	collector.Collect(ch)
+	close(ch)
+
+	if got := len(ch); got != 10 {
+		t.Fatalf("got %v, want 10", got)
+	}
+
+	// L1 heads, then the L2 heads interleaved with their L1 origins
+	for _, want := range []float64{100, 101, 90, 60, 5000, 99, 4800, 89, 4000, 59} {
+		var metric dto.Metric
+		if err := (<-ch).Write(&metric); err != nil {
+			t.Fatalf("expected metric, got %#v", err)
+		}
+		if got := *metric.Gauge.Value; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
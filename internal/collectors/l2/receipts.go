@@ -0,0 +1,69 @@
+package l2
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// depositTxType is the type of OP-stack deposit transactions, which pay no L1 fee.
+const depositTxType = 0x7e
+
+type l2Block struct {
+	Number       hexutil.Uint64
+	Timestamp    hexutil.Uint64
+	Transactions []common.Hash
+	// L1BlockNumber is reported by Arbitrum.
+	L1BlockNumber *hexutil.Uint64 `json:"l1BlockNumber"`
+}
+
+// l2Receipt holds the L1 fee fields added to receipts by OP-stack and Arbitrum chains.
+type l2Receipt struct {
+	Type hexutil.Uint64
+	// OP-stack fields.
+	L1Fee       *hexutil.Big `json:"l1Fee"`
+	L1GasUsed   *hexutil.Big `json:"l1GasUsed"`
+	L1GasPrice  *hexutil.Big `json:"l1GasPrice"`
+	L1FeeScalar string       `json:"l1FeeScalar"`
+	// Arbitrum fields.
+	GasUsedForL1 *hexutil.Big `json:"gasUsedForL1"`
+}
+
+func getBlock(ctx context.Context, rpc *rpc.Client, number interface{}) (*l2Block, error) {
+	var block *l2Block
+	if err := rpc.CallContext(ctx, &block, "eth_getBlockByNumber", number, false); err != nil {
+		return nil, errors.Wrapf(err, "failed to get block %v", number)
+	}
+	if block == nil {
+		return nil, errors.Errorf("block %v not found", number)
+	}
+	return block, nil
+}
+
+// getReceipts fetches the receipts of every transaction in block with a single batch request.
+func getReceipts(ctx context.Context, client *rpc.Client, block *l2Block) ([]l2Receipt, error) {
+	receipts := make([]l2Receipt, len(block.Transactions))
+	batch := make([]rpc.BatchElem, len(block.Transactions))
+	for i, hash := range block.Transactions {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+	if len(batch) == 0 {
+		return receipts, nil
+	}
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return nil, errors.Wrap(err, "failed to get receipts")
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return nil, errors.Wrap(elem.Error, "failed to get receipt")
+		}
+	}
+	return receipts, nil
+}
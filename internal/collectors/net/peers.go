@@ -1,6 +1,8 @@
 package net
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
@@ -29,8 +31,12 @@ func (collector *NetPeerCount) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (collector *NetPeerCount) Collect(ch chan<- prometheus.Metric) {
+	collector.CollectContext(context.Background(), ch)
+}
+
+func (collector *NetPeerCount) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	var result hexutil.Uint64
-	if err := collector.rpc.Call(&result, "net_peerCount"); err != nil {
+	if err := collector.rpc.CallContext(ctx, &result, "net_peerCount"); err != nil {
 		ch <- prometheus.NewInvalidMetric(collector.desc, err)
 		return
 	}
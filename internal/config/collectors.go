@@ -0,0 +1,129 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/catalog"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+// collectorRequirement returns the setting a collector needs, and whether it is configured.
+func (c *Config) collectorRequirement(name string) (string, bool) {
+	switch name {
+	case "balance_snapshot":
+		return "balance_history", c.BalanceHistory.BlockInterval > 0 || c.BalanceHistory.Daily
+	case "admin_peers", "admin_node_info":
+		return "general.admin_api", c.General.AdminAPI
+	case "beacon_syncing", "beacon_peers", "beacon_head_slot", "beacon_finality", "beacon_validators":
+		return "general.beacon_provider_url", c.General.BeaconProviderURL != ""
+	case "optimism_l1_fees":
+		return "the optimism general.l2_type", c.General.L2Type == "optimism"
+	case "optimism_sync_status":
+		return "general.l2_rollup_url", c.General.L2Type == "optimism" && c.General.L2RollupURL != ""
+	case "arbitrum_l1_fees", "arbitrum_batch_posting":
+		return "the arbitrum general.l2_type", c.General.L2Type == "arbitrum"
+	}
+	return "", true
+}
+
+// CollectorEnabled returns whether the named collector is enabled, either explicitly or, by default, when its
+// requirements are configured.
+func (c *Config) CollectorEnabled(name string) bool {
+	if collector, ok := c.Collectors[name]; ok && collector.Enabled != nil {
+		return *collector.Enabled
+	}
+	_, ok := c.collectorRequirement(name)
+	return ok
+}
+
+// CollectorUnit returns the unit option of the named collector, or def when unset.
+func (c *Config) CollectorUnit(name string, def units.Unit) units.Unit {
+	unit, err := units.Parse(c.Collectors[name].Options["unit"], def)
+	if err != nil {
+		// rejected by validation
+		return def
+	}
+	return unit
+}
+
+// CollectorBool returns a bool option of the named collector, or def when unset.
+func (c *Config) CollectorBool(name, option string, def bool) bool {
+	value, err := strconv.ParseBool(c.Collectors[name].Options[option])
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+func (c *checker) checkCollectors(config *Config) {
+	for _, name := range sortedKeys(config.Collectors) {
+		collectorConfig := config.Collectors[name]
+		p := path{"collectors", name}
+		collector, ok := catalog.Lookup(name)
+		if !ok {
+			c.addf(p, "unknown collector, see -list-collectors")
+			continue
+		}
+		if collectorConfig.Enabled != nil && *collectorConfig.Enabled {
+			if requirement, ok := config.collectorRequirement(name); !ok {
+				c.addf(p.with("enabled"), "requires %s", requirement)
+			}
+		}
+		if collectorConfig.Timeout < 0 {
+			c.addf(p.with("timeout"), "must not be negative")
+		}
+		for _, optionName := range sortedKeys(collectorConfig.Options) {
+			value := collectorConfig.Options[optionName]
+			option, ok := collector.Option(optionName)
+			if !ok {
+				c.addf(p.with("options", optionName), "unknown option of the %s collector", name)
+				continue
+			}
+			switch option.Kind {
+			case catalog.UnitOption:
+				if _, err := units.Parse(value, units.Wei); err != nil {
+					c.addf(p.with("options", optionName), "%v", err)
+				}
+			case catalog.BoolOption:
+				if _, err := strconv.ParseBool(value); err != nil {
+					c.addf(p.with("options", optionName), "must be true or false, got %q", value)
+				}
+			}
+		}
+	}
+}
+
+// checkBalanceUnits reports a wallet_balance_threshold collector exporting thresholds in another unit than the balance
+// collector, since alerts compare both.
+func (c *checker) checkBalanceUnits(config *Config) {
+	if !config.CollectorEnabled("balance") || !config.CollectorEnabled("wallet_balance_threshold") {
+		return
+	}
+	def, err := units.Parse(config.General.BalanceUnit, units.Ether)
+	if err != nil {
+		// reported with the general section
+		return
+	}
+	balance, balanceErr := units.Parse(config.Collectors["balance"].Options["unit"], def)
+	threshold, thresholdErr := units.Parse(config.Collectors["wallet_balance_threshold"].Options["unit"], def)
+	if balanceErr != nil || thresholdErr != nil || balance == threshold {
+		return
+	}
+	p := path{"collectors", "wallet_balance_threshold", "options", "unit"}
+	if config.Collectors["wallet_balance_threshold"].Options["unit"] == "" {
+		p = path{"collectors", "balance", "options", "unit"}
+	}
+	c.addf(p, "the balance and wallet_balance_threshold collectors must use the same unit, got %s and %s", balance, threshold)
+}
+
+// sortedKeys returns the keys of a map with string keys, sorted.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
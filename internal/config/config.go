@@ -1,44 +1,189 @@
 package config
 
 import (
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
+	"strconv"
+	"time"
 )
 
 type ERC20Target struct {
-	Name         string `yaml:"name"`
-	ContractAddr string `yaml:"contract"`
+	Name         string `yaml:"name" json:"name"`
+	ContractAddr string `yaml:"contract" json:"contract"`
 }
 
 type WalletTarget struct {
-	Addr string `yaml:"address"`
-	Name string `yaml:"name"`
+	Addr string `yaml:"address" json:"address"`
+	Name string `yaml:"name" json:"name"`
+	// MinBalance and MaxBalance are optional balance thresholds, in ether.
+	MinBalance *float64 `yaml:"min_balance" json:"min_balance"`
+	MaxBalance *float64 `yaml:"max_balance" json:"max_balance"`
 }
 
-type Config struct {
-	General struct {
-		EthProviderURL    string `yaml:"eth_provider_url"`
-		EthBlockchainName string `yaml:"eth_blockchain_name"`
-		ServerURL         string `yaml:"server_url"`
-		StartBlockNumber  uint64 `yaml:"start_block_number"`
-	} `yaml:"general"`
-	Target struct {
-		ERC20   []ERC20Target  `yaml:"erc20"`
-		Wallets []WalletTarget `yaml:"wallets"`
-	} `yaml:"targets"`
+// ValidatorTarget identifies a beacon chain validator by either its index or its public key.
+type ValidatorTarget struct {
+	Name   string  `yaml:"name" json:"name"`
+	Index  *uint64 `yaml:"index" json:"index"`
+	Pubkey string  `yaml:"pubkey" json:"pubkey"`
 }
 
-func ParseConfigFromFile(path string) (*Config, error) {
-	bytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
+// ID returns the identifier of the validator as accepted by the Beacon API.
+func (t ValidatorTarget) ID() string {
+	if t.Index != nil {
+		return strconv.FormatUint(*t.Index, 10)
 	}
+	return t.Pubkey
+}
 
-	config := new(Config)
-	err = yaml.Unmarshal(bytes, config)
-	if err != nil {
-		return nil, err
-	}
+// Targets are the contracts, wallets and validators to monitor.
+type Targets struct {
+	ERC20      []ERC20Target     `yaml:"erc20" json:"erc20"`
+	Wallets    []WalletTarget    `yaml:"wallets" json:"wallets"`
+	Validators []ValidatorTarget `yaml:"validators" json:"validators"`
+}
 
-	return config, nil
+// TargetSource is an external source of targets, refreshed periodically.
+type TargetSource struct {
+	// Type is one of file, http or token_list.
+	Type string `yaml:"type"`
+	// Directory holds the target files of a file source, in the format of the targets section.
+	Directory string `yaml:"directory"`
+	// URL serves targets as JSON for an http source, or a Uniswap-format token list for a token_list source.
+	URL string `yaml:"url"`
+	// ChainID selects the tokens of a token_list source, and defaults to general.eth_chain_id.
+	ChainID         uint64        `yaml:"chain_id"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// ProviderConfig configures the authentication and the transport of the requests sent to a provider, and only applies
+// to HTTP providers. At most one of BasicAuth, BearerToken, BearerTokenFile or JWTSecretFile can be set.
+type ProviderConfig struct {
+	// Headers are added to every request, such as the API key header of hosted providers.
+	Headers   map[string]string `yaml:"headers"`
+	BasicAuth *BasicAuth        `yaml:"basic_auth"`
+	// BearerToken, or the content of BearerTokenFile read on every request, is sent as a bearer token.
+	BearerToken     string `yaml:"bearer_token"`
+	BearerTokenFile string `yaml:"bearer_token_file"`
+	// JWTSecretFile holds the hex-encoded secret signing short-lived tokens, as the engine API authentication of
+	// execution clients expects.
+	JWTSecretFile string `yaml:"jwt_secret_file"`
+
+	TLSConfig TLSConfig `yaml:"tls_config"`
+	// ProxyURL is the proxy requests are sent through, instead of the one set by the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables.
+	ProxyURL string `yaml:"proxy_url"`
+	// Timeout bounds every request, including reading the response, and DialTimeout opening a connection. Zero means
+	// no timeout, and the default dial timeout of 30s respectively.
+	Timeout     time.Duration `yaml:"timeout"`
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+	// MaxIdleConns and MaxConnsPerHost size the connection pool, and IdleConnTimeout is how long idle connections are
+	// kept. Zero means the defaults of 100 idle connections, unlimited connections and 90s respectively.
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	MaxConnsPerHost int           `yaml:"max_conns_per_host"`
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout"`
+
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Retry     Retry     `yaml:"retry"`
+}
+
+// RateLimit configures the token bucket the requests to a provider wait on, shared by every collector querying it.
+type RateLimit struct {
+	// RequestsPerSecond is the rate the bucket fills at, with every JSON-RPC call taking the weight of its method. Zero
+	// disables rate limiting.
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// Burst is the capacity of the bucket, defaulting to RequestsPerSecond and at least 1. Requests costing more,
+	// such as large batches, wait for the bucket to refill their whole cost.
+	Burst float64 `yaml:"burst"`
+	// MethodWeights are the compute units of the methods, as priced by the provider, 1 when unset. With weights, the
+	// rate is in compute units per second.
+	MethodWeights map[string]float64 `yaml:"method_weights"`
+}
+
+// Retry configures the retries of requests failing with a retryable error: network errors, 429 Too Many Requests,
+// 502, 503 and 504 responses, and JSON-RPC rate limiting errors.
+type Retry struct {
+	// MaxRetries is the number of retries after the first attempt. Zero disables retries.
+	MaxRetries int `yaml:"max_retries"`
+	// InitialBackoff doubles with every retry up to MaxBackoff, defaulting to 100ms and 5s, and the wait before a
+	// retry is picked at random between half and all of it. A Retry-After header sets the wait instead, up to
+	// MaxBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// TLSConfig configures the TLS connections to a provider.
+type TLSConfig struct {
+	// CAFile holds the PEM-encoded CA certificates verifying the provider, instead of the system ones.
+	CAFile string `yaml:"ca_file"`
+	// CertFile and KeyFile hold the PEM-encoded client certificate and key, for providers requiring mutual TLS.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ServerName overrides the name the provider certificate is verified against.
+	ServerName string `yaml:"server_name"`
+	// InsecureSkipVerify disables the verification of the provider certificate. Only meant for test setups.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// BasicAuth holds the credentials of HTTP basic authentication. PasswordFile is read on every request.
+type BasicAuth struct {
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+}
+
+// CollectorConfig toggles a collector of the catalog and sets its options.
+type CollectorConfig struct {
+	// Enabled defaults to whether the requirements of the collector, such as admin_api, are configured.
+	Enabled *bool             `yaml:"enabled"`
+	Options map[string]string `yaml:"options"`
+	// Timeout bounds the scrapes of the collector, within the scrape timeout of Prometheus.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type Config struct {
+	General struct {
+		EthProviderURL    string `yaml:"eth_provider_url"`
+		EthBlockchainName string `yaml:"eth_blockchain_name"`
+		// EthChainID is the chain id the provider is expected to serve. It is only checked when set.
+		EthChainID uint64 `yaml:"eth_chain_id"`
+		// EthMaxBatchSize caps the calls of the batch requests grouping the calls of the eth collectors, 100 when
+		// unset. A size of 1 disables batching.
+		EthMaxBatchSize uint64 `yaml:"eth_max_batch_size"`
+		// EthCacheTTL is how long the results of the calls of the eth collectors are reused by later scrapes.
+		// Identical calls of concurrent scrapes are always sent once.
+		EthCacheTTL      time.Duration `yaml:"eth_cache_ttl"`
+		ServerURL        string        `yaml:"server_url"`
+		StartBlockNumber uint64        `yaml:"start_block_number"`
+		// EventBlockTag and EventConfirmations limit the blocks indexed by the event collectors, to those reaching
+		// the safe or finalized tag, or to those a number of confirmations deep.
+		EventBlockTag      string `yaml:"event_block_tag"`
+		EventConfirmations uint64 `yaml:"event_confirmations"`
+		// BeaconProviderURL is the optional Beacon API endpoint of the consensus-layer client.
+		BeaconProviderURL string `yaml:"beacon_provider_url"`
+		// AdminAPI enables the collectors that rely on the node's admin namespace.
+		AdminAPI bool `yaml:"admin_api"`
+		// BalanceUnit and GasPriceUnit are one of wei, gwei or ether.
+		BalanceUnit  string `yaml:"balance_unit"`
+		GasPriceUnit string `yaml:"gas_price_unit"`
+		// ExportExactValues exposes exact wei amounts as labels on info metrics.
+		ExportExactValues bool `yaml:"export_exact_values"`
+		// L2Type enables the L2-specific collectors, either optimism or arbitrum.
+		L2Type string `yaml:"l2_type"`
+		// L2RollupURL is the optional endpoint of the OP-stack rollup node, serving optimism_syncStatus.
+		L2RollupURL string `yaml:"l2_rollup_url"`
+	} `yaml:"general"`
+	// Providers configures the requests sent to eth_provider_url, beacon_provider_url and l2_rollup_url.
+	Providers struct {
+		Eth      ProviderConfig `yaml:"eth"`
+		Beacon   ProviderConfig `yaml:"beacon"`
+		L2Rollup ProviderConfig `yaml:"l2_rollup"`
+	} `yaml:"providers"`
+	// Collectors are keyed by collector name.
+	Collectors    map[string]CollectorConfig `yaml:"collectors"`
+	Target        Targets                    `yaml:"targets"`
+	TargetSources []TargetSource             `yaml:"target_sources"`
+	// BalanceHistory configures wallet balance snapshots, taken every BlockInterval blocks and/or at the first
+	// block of each UTC day.
+	BalanceHistory struct {
+		BlockInterval uint64 `yaml:"block_interval"`
+		Daily         bool   `yaml:"daily"`
+	} `yaml:"balance_history"`
 }
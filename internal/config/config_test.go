@@ -1,41 +1,98 @@
 package config
 
 import (
-	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
 )
 
-func TestParseConfigFromFileIsSuccessful(t *testing.T) {
-	config, err := ParseConfigFromFile("test_data/test_config.yaml")
-	assert.Nil(t, err, "error expected to be nil")
+func TestLoadConfigFromFileDecodesEveryField(t *testing.T) {
+	config, err := LoadConfigFromFile("test_data/test_config.yaml", nil)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
 
 	// General
-	assert.Equal(t, "abc", config.General.EthProviderURL)
+	assert.Equal(t, "http://geth:8545", config.General.EthProviderURL)
+	assert.Equal(t, "http://beacon:5052", config.General.BeaconProviderURL)
+	assert.True(t, config.General.AdminAPI)
 	assert.Equal(t, "some blockchain name", config.General.EthBlockchainName)
-	assert.Equal(t, "qwe", config.General.ServerURL)
+	assert.Equal(t, uint64(5), config.General.EthChainID)
+	assert.Equal(t, uint64(50), config.General.EthMaxBatchSize)
+	assert.Equal(t, 5*time.Second, config.General.EthCacheTTL)
+	assert.Equal(t, ":9368", config.General.ServerURL)
 	assert.Equal(t, uint64(123), config.General.StartBlockNumber)
+	assert.Equal(t, "finalized", config.General.EventBlockTag)
+	assert.Zero(t, config.General.EventConfirmations)
+	assert.Equal(t, "gwei", config.General.BalanceUnit)
+	assert.Equal(t, "wei", config.General.GasPriceUnit)
+	assert.True(t, config.General.ExportExactValues)
+	assert.Equal(t, "optimism", config.General.L2Type)
+	assert.Equal(t, "http://op-node:9545", config.General.L2RollupURL)
+	// Providers
+	assert.Equal(t, map[string]string{"X-Api-Key": "key"}, config.Providers.Eth.Headers)
+	assert.Equal(t, "/etc/ethereum/jwt.hex", config.Providers.Eth.JWTSecretFile)
+	assert.Equal(t, "/etc/ethereum/ca.pem", config.Providers.Eth.TLSConfig.CAFile)
+	assert.Equal(t, "/etc/ethereum/client.pem", config.Providers.Eth.TLSConfig.CertFile)
+	assert.Equal(t, "/etc/ethereum/client-key.pem", config.Providers.Eth.TLSConfig.KeyFile)
+	assert.Equal(t, "http://proxy:3128", config.Providers.Eth.ProxyURL)
+	assert.Equal(t, 10*time.Second, config.Providers.Eth.Timeout)
+	assert.Equal(t, 16, config.Providers.Eth.MaxConnsPerHost)
+	assert.Equal(t, 330.0, config.Providers.Eth.RateLimit.RequestsPerSecond)
+	assert.Equal(t, map[string]float64{"eth_getLogs": 75}, config.Providers.Eth.RateLimit.MethodWeights)
+	assert.Equal(t, 3, config.Providers.Eth.Retry.MaxRetries)
+	assert.Equal(t, "user", config.Providers.Beacon.BasicAuth.Username)
+	assert.Equal(t, "/etc/ethereum/beacon_password", config.Providers.Beacon.BasicAuth.PasswordFile)
+	assert.Nil(t, config.Providers.L2Rollup.BasicAuth)
 	// Targets - ERC-20
 	assert.Len(t, config.Target.ERC20, 2)
 	assert.Equal(t, "usdt falopa", config.Target.ERC20[0].Name)
-	assert.Equal(t, "0x123123", config.Target.ERC20[0].ContractAddr)
+	assert.Equal(t, "0xdAC17F958D2ee523a2206206994597C13D831ec7", config.Target.ERC20[0].ContractAddr)
 	assert.Equal(t, "usdt falopa 2", config.Target.ERC20[1].Name)
-	assert.Equal(t, "0x123124", config.Target.ERC20[1].ContractAddr)
+	assert.Equal(t, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", config.Target.ERC20[1].ContractAddr)
 	// Targets - Wallets
-	assert.Equal(t, "0x123", config.Target.Wallets[0].Addr)
+	assert.Equal(t, "0x742d35cc6634c0532925a3b844bc454e4438f44e", config.Target.Wallets[0].Addr)
 	assert.Equal(t, "wallet 1", config.Target.Wallets[0].Name)
+	assert.Equal(t, 0.5, *config.Target.Wallets[0].MinBalance)
+	assert.Equal(t, float64(10), *config.Target.Wallets[0].MaxBalance)
 	// Targets - Wallets
-	assert.Equal(t, "0x456", config.Target.Wallets[1].Addr)
+	assert.Equal(t, "0xde0b295669a9fd93d5f28d9ec85e40f4cb697bae", config.Target.Wallets[1].Addr)
 	assert.Equal(t, "wallet 2", config.Target.Wallets[1].Name)
+	assert.Nil(t, config.Target.Wallets[1].MinBalance)
+	assert.Nil(t, config.Target.Wallets[1].MaxBalance)
+	// Targets - Validators
+	assert.Len(t, config.Target.Validators, 2)
+	assert.Equal(t, "validator 1", config.Target.Validators[0].Name)
+	assert.Equal(t, "1234", config.Target.Validators[0].ID())
+	assert.Equal(t, "validator 2", config.Target.Validators[1].Name)
+	assert.Equal(t, "0xa1d1ad0714035353258038e964ae9675dc0252ee22cea896825c01458e1807bfad2f9969338798548d9858a571f7425c", config.Target.Validators[1].ID())
+	// Target sources
+	assert.Len(t, config.TargetSources, 2)
+	assert.Equal(t, "file", config.TargetSources[0].Type)
+	assert.Equal(t, "/etc/ethereum_exporter/targets", config.TargetSources[0].Directory)
+	assert.Zero(t, config.TargetSources[0].RefreshInterval)
+	assert.Equal(t, "token_list", config.TargetSources[1].Type)
+	assert.Equal(t, "https://tokens.example.org/list.json", config.TargetSources[1].URL)
+	assert.Equal(t, uint64(1), config.TargetSources[1].ChainID)
+	assert.Equal(t, time.Hour, config.TargetSources[1].RefreshInterval)
+	// Balance history
+	assert.Equal(t, uint64(7200), config.BalanceHistory.BlockInterval)
+	assert.True(t, config.BalanceHistory.Daily)
+	// Collectors
+	assert.False(t, config.CollectorEnabled("hashrate"))
+	assert.True(t, config.CollectorEnabled("syncing"))
+	assert.True(t, config.CollectorEnabled("admin_peers"))
+	assert.False(t, config.CollectorEnabled("arbitrum_l1_fees"))
+	assert.Equal(t, units.Gwei, config.CollectorUnit("gas_price", units.Wei))
+	assert.False(t, config.CollectorBool("gas_price", "export_exact_values", true))
+	assert.Equal(t, units.Ether, config.CollectorUnit("balance", units.Ether))
+	assert.Equal(t, 5*time.Second, config.Collectors["gas_price"].Timeout)
 }
 
-func TestParseConfigFromFileFailsWithNonExistentFile(t *testing.T) {
-	config, err := ParseConfigFromFile("abc_test_config.yaml")
-	assert.NotNil(t, err)
-	assert.Nil(t, config)
-}
-
-func TestParseConfigFromFileFailsWithBadFormattedData(t *testing.T) {
-	config, err := ParseConfigFromFile("test_data/bad_formatted_config.yaml")
+func TestLoadConfigFromFileFailsWithNonExistentFile(t *testing.T) {
+	config, err := LoadConfigFromFile("abc_test_config.yaml", nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, config)
 }
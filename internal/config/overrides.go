@@ -0,0 +1,233 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/catalog"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variables overriding the general section, such as ETH_EXPORTER_PROVIDER_URL
+// for eth_provider_url.
+const EnvPrefix = "ETH_EXPORTER_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// envReference matches ${VAR} references in config values, and the $$ escape for a literal $.
+var envReference = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// collectorsPrefix prefixes the overrides of the collectors section, keyed by their path in the section such as
+// collectors.hashrate.enabled or collectors.gas_price.options.unit.
+const collectorsPrefix = "collectors."
+
+// Overrides holds general section values set outside the config file, keyed by their yaml field name, and the
+// collectors section values set by flags.
+type Overrides map[string]string
+
+type overrideFlag struct {
+	overrides Overrides
+	name      string
+	isBool    bool
+	// negate stores the opposite of the value of a bool flag, for -no-collector.<name> flags.
+	negate bool
+}
+
+func (f *overrideFlag) String() string {
+	if f.overrides == nil {
+		return ""
+	}
+	return f.overrides[f.name]
+}
+
+func (f *overrideFlag) Set(value string) error {
+	if f.negate {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		value = strconv.FormatBool(!b)
+	}
+	f.overrides[f.name] = value
+	return nil
+}
+
+func (f *overrideFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// generalFields returns the scalar and duration fields of the general section by yaml field name.
+func generalFields(config *Config) map[string]reflect.Value {
+	fields := map[string]reflect.Value{}
+	general := reflect.ValueOf(&config.General).Elem()
+	for i := 0; i < general.NumField(); i++ {
+		name := strings.Split(general.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		field := general.Field(i)
+		switch {
+		case field.Type() == durationType:
+			fields[name] = field
+		case field.Kind() == reflect.String, field.Kind() == reflect.Bool, field.Kind() == reflect.Uint64:
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// EnvName returns the environment variable overriding a general section field.
+func EnvName(field string) string {
+	return EnvPrefix + strings.ToUpper(strings.TrimPrefix(field, "eth_"))
+}
+
+// FlagName returns the flag overriding a general section field.
+func FlagName(field string) string {
+	return strings.ReplaceAll(field, "_", "-")
+}
+
+// RegisterFlags registers a flag on fs for every field of the general section, along with the -collector.<name>,
+// -no-collector.<name> and -collector.<name>.<option> flags of every collector, and returns the overrides they set
+// once fs is parsed.
+func RegisterFlags(fs *flag.FlagSet) Overrides {
+	overrides := Overrides{}
+	for name, field := range generalFields(new(Config)) {
+		fs.Var(&overrideFlag{
+			overrides: overrides,
+			name:      name,
+			isBool:    field.Kind() == reflect.Bool,
+		}, FlagName(name), fmt.Sprintf("general.%s, overriding the config file and the %s environment variable", name, EnvName(name)))
+	}
+	for _, collector := range catalog.Collectors {
+		enabled := collectorsPrefix + collector.Name + ".enabled"
+		fs.Var(&overrideFlag{overrides: overrides, name: enabled, isBool: true},
+			"collector."+collector.Name, fmt.Sprintf("enable the %s collector: %s", collector.Name, collector.Help))
+		fs.Var(&overrideFlag{overrides: overrides, name: enabled, isBool: true, negate: true},
+			"no-collector."+collector.Name, fmt.Sprintf("disable the %s collector", collector.Name))
+		for _, option := range collector.Options {
+			fs.Var(&overrideFlag{
+				overrides: overrides,
+				name:      collectorsPrefix + collector.Name + ".options." + option.Name,
+				isBool:    option.Kind == catalog.BoolOption,
+			}, "collector."+collector.Name+"."+option.Name, fmt.Sprintf("%s of the %s collector", option.Help, collector.Name))
+		}
+	}
+	return overrides
+}
+
+// envOverrides returns the general section fields set through environment variables.
+func envOverrides() Overrides {
+	overrides := Overrides{}
+	for name := range generalFields(new(Config)) {
+		if value, ok := os.LookupEnv(EnvName(name)); ok {
+			overrides[name] = value
+		}
+	}
+	return overrides
+}
+
+// apply sets the overridden fields of the general section, reporting values that don't fit their field.
+func (o Overrides) apply(config *Config, source func(name string) string) []Problem {
+	var problems []Problem
+	fields := generalFields(config)
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := o[name]
+		if strings.HasPrefix(name, collectorsPrefix) {
+			applyCollectorOverride(config, strings.TrimPrefix(name, collectorsPrefix), value)
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			continue
+		}
+		var err error
+		switch field.Kind() {
+		case reflect.Int64:
+			var d time.Duration
+			if d, err = time.ParseDuration(value); err == nil {
+				field.SetInt(int64(d))
+			}
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(value); err == nil {
+				field.SetBool(b)
+			}
+		case reflect.Uint64:
+			var n uint64
+			if n, err = strconv.ParseUint(value, 10, 64); err == nil {
+				field.SetUint(n)
+			}
+		}
+		if err != nil {
+			problems = append(problems, Problem{
+				Path:    source(name),
+				Message: fmt.Sprintf("invalid value %q for general.%s", value, name),
+			})
+		}
+	}
+	return problems
+}
+
+// applyCollectorOverride sets the enabled field or an option of a collector, from its path in the collectors
+// section. Values are checked along the collectors section.
+func applyCollectorOverride(config *Config, path string, value string) {
+	elems := strings.SplitN(path, ".", 3)
+	if config.Collectors == nil {
+		config.Collectors = map[string]CollectorConfig{}
+	}
+	collector := config.Collectors[elems[0]]
+	switch {
+	case len(elems) == 2 && elems[1] == "enabled":
+		enabled, _ := strconv.ParseBool(value)
+		collector.Enabled = &enabled
+	case len(elems) == 3 && elems[1] == "options":
+		if collector.Options == nil {
+			collector.Options = map[string]string{}
+		}
+		collector.Options[elems[2]] = value
+	default:
+		return
+	}
+	config.Collectors[elems[0]] = collector
+}
+
+// expandEnv replaces ${VAR} references in the scalar values of node with the value of the environment variable,
+// reporting references to unset variables.
+func expandEnv(node *yaml.Node) []Problem {
+	var problems []Problem
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "$") {
+		node.Value = envReference.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			if ref == "$$" {
+				return "$"
+			}
+			name := envReference.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				problems = append(problems, Problem{
+					Line:    node.Line,
+					Message: fmt.Sprintf("environment variable %s is not set", name),
+				})
+			}
+			return value
+		})
+		if node.Style&(yaml.TaggedStyle|yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 && node.Tag == "!!str" {
+			// resolve plain values again, so references to numbers and booleans decode as such
+			node.Tag = ""
+		}
+	}
+	for _, child := range node.Content {
+		problems = append(problems, expandEnv(child)...)
+	}
+	return problems
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+)
+
+func setenv(t *testing.T, env map[string]string) {
+	for name, value := range env {
+		assert.Nil(t, os.Setenv(name, value))
+	}
+	t.Cleanup(func() {
+		for name := range env {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+func TestLoadConfigFromFileExpandsEnv(t *testing.T) {
+	setenv(t, map[string]string{
+		"TEST_PROVIDER_KEY":   "secret",
+		"TEST_CHAIN_ID":       "5",
+		"TEST_UNSET_VARIABLE": "",
+	})
+
+	config, err := LoadConfigFromFile("test_data/env_config.yaml", nil)
+	assert.Nil(t, err, "error expected to be nil")
+
+	assert.Equal(t, "https://mainnet.example.org/v3/secret", config.General.EthProviderURL)
+	assert.Equal(t, uint64(5), config.General.EthChainID)
+	assert.Equal(t, "pa$word wallet ", config.Target.Wallets[0].Name)
+}
+
+func TestLoadConfigFromFileReportsUnsetEnv(t *testing.T) {
+	setenv(t, map[string]string{"TEST_CHAIN_ID": "5"})
+
+	_, err := LoadConfigFromFile("test_data/env_config.yaml", nil)
+	validationErr, ok := err.(*ValidationError)
+	if !assert.True(t, ok, "expected a validation error, got %v", err) {
+		return
+	}
+
+	var problems []string
+	for _, p := range validationErr.Problems {
+		problems = append(problems, p.String())
+	}
+	assert.Equal(t, []string{
+		"line 2: environment variable TEST_PROVIDER_KEY is not set",
+		"line 9: environment variable TEST_UNSET_VARIABLE is not set",
+	}, problems)
+}
+
+func TestLoadConfigFromFileOverrides(t *testing.T) {
+	setenv(t, map[string]string{
+		"TEST_PROVIDER_KEY":            "secret",
+		"TEST_CHAIN_ID":                "5",
+		"TEST_UNSET_VARIABLE":          "",
+		"ETH_EXPORTER_PROVIDER_URL":    "https://env.example.org",
+		"ETH_EXPORTER_BLOCKCHAIN_NAME": "from env",
+		"ETH_EXPORTER_CHAIN_ID":        "1",
+	})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	overrides := RegisterFlags(fs)
+	assert.Nil(t, fs.Parse([]string{"-eth-provider-url", "https://flag.example.org", "-export-exact-values", "-eth-cache-ttl", "3s"}))
+
+	config, err := LoadConfigFromFile("test_data/env_config.yaml", overrides)
+	assert.Nil(t, err, "error expected to be nil")
+
+	// flags take precedence over the environment, which takes precedence over the file
+	assert.Equal(t, "https://flag.example.org", config.General.EthProviderURL)
+	assert.Equal(t, "from env", config.General.EthBlockchainName)
+	assert.Equal(t, uint64(1), config.General.EthChainID)
+	assert.Equal(t, "gwei", config.General.GasPriceUnit)
+	assert.True(t, config.General.ExportExactValues)
+	assert.Equal(t, 3*time.Second, config.General.EthCacheTTL)
+}
+
+func TestLoadConfigFromFileReportsInvalidOverrides(t *testing.T) {
+	setenv(t, map[string]string{
+		"TEST_PROVIDER_KEY":     "secret",
+		"TEST_CHAIN_ID":         "5",
+		"TEST_UNSET_VARIABLE":   "",
+		"ETH_EXPORTER_CHAIN_ID": "mainnet",
+	})
+
+	_, err := LoadConfigFromFile("test_data/env_config.yaml", nil)
+	validationErr, ok := err.(*ValidationError)
+	if !assert.True(t, ok, "expected a validation error, got %v", err) {
+		return
+	}
+	assert.Equal(t, 1, len(validationErr.Problems))
+	assert.Equal(t, `environment variable ETH_EXPORTER_CHAIN_ID: invalid value "mainnet" for general.eth_chain_id`, validationErr.Problems[0].String())
+}
+
+func TestLoadConfigFromFileCollectorFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	overrides := RegisterFlags(fs)
+	assert.Nil(t, fs.Parse([]string{"-no-collector.syncing", "-no-collector.beacon_peers", "-collector.gas_price.unit", "ether", "-collector.gas_price.export_exact_values"}))
+
+	config, err := LoadConfigFromFile("test_data/valid_config.yaml", overrides)
+	assert.Nil(t, err, "error expected to be nil")
+
+	assert.False(t, config.CollectorEnabled("syncing"))
+	assert.False(t, config.CollectorEnabled("beacon_peers"))
+	assert.True(t, config.CollectorEnabled("beacon_syncing"))
+	assert.False(t, config.CollectorEnabled("admin_peers"))
+	assert.Equal(t, units.Ether, config.CollectorUnit("gas_price", units.Wei))
+	assert.True(t, config.CollectorBool("gas_price", "export_exact_values", false))
+}
+
+func TestLoadConfigFromFileReportsInvalidCollectorFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	overrides := RegisterFlags(fs)
+	assert.Nil(t, fs.Parse([]string{"-collector.arbitrum_l1_fees", "-collector.balance.unit", "finney"}))
+
+	_, err := LoadConfigFromFile("test_data/valid_config.yaml", overrides)
+	validationErr, ok := err.(*ValidationError)
+	if !assert.True(t, ok, "expected a validation error, got %v", err) {
+		return
+	}
+
+	var problems []string
+	for _, p := range validationErr.Problems {
+		problems = append(problems, p.String())
+	}
+	assert.Equal(t, []string{
+		"line 1: collectors.arbitrum_l1_fees.enabled: requires the arbitrum general.l2_type",
+		`line 1: collectors.balance.options.unit: unknown unit "finney", expected one of wei, gwei or ether`,
+	}, problems)
+}
@@ -0,0 +1,534 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/units"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// typeErrorLine matches the line prefix of the messages of a yaml.TypeError.
+	typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+	pubkeyPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{96}$`)
+)
+
+// Problem is a single invalid value in a config file.
+type Problem struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		// the value doesn't come from the config file
+		return fmt.Sprintf("%s: %s", p.Path, p.Message)
+	}
+	if p.Path == "" {
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Path, p.Message)
+}
+
+// ValidationError lists every problem found in a config file.
+type ValidationError struct {
+	File     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s has %d problem(s):", e.File, len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+// LoadConfigFromFile parses a config file and validates it, reporting every problem found at once in a
+// ValidationError. Unknown fields are rejected, ${VAR} references are replaced with the value of the environment
+// variable, and general section fields can be overridden. Flags take precedence over
+// environment variables, which take precedence over the config file.
+func LoadConfigFromFile(path string, flags Overrides) (*Config, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(bytes, &root); err != nil {
+		return nil, err
+	}
+
+	c := &checker{root: &root}
+	config := new(Config)
+	if len(root.Content) == 0 {
+		c.problems = append(c.problems, Problem{Line: 1, Message: "config is empty"})
+	} else {
+		c.problems = append(c.problems, expandEnv(&root)...)
+		c.checkKnownFields(root.Content[0], reflect.TypeOf(config).Elem(), nil)
+		if err := root.Decode(config); err != nil {
+			typeErr, ok := err.(*yaml.TypeError)
+			if !ok {
+				return nil, err
+			}
+			for _, msg := range typeErr.Errors {
+				problem := Problem{Message: msg}
+				if m := typeErrorLine.FindStringSubmatch(msg); m != nil {
+					problem.Line, _ = strconv.Atoi(m[1])
+					problem.Message = m[2]
+				}
+				c.problems = append(c.problems, problem)
+			}
+		}
+		c.problems = append(c.problems, envOverrides().apply(config, func(name string) string {
+			return "environment variable " + EnvName(name)
+		})...)
+		c.problems = append(c.problems, flags.apply(config, func(name string) string {
+			return "flag -" + FlagName(name)
+		})...)
+		c.check(config)
+	}
+
+	if len(c.problems) > 0 {
+		sort.SliceStable(c.problems, func(i, j int) bool {
+			return c.problems[i].Line < c.problems[j].Line
+		})
+		return nil, &ValidationError{File: path, Problems: c.problems}
+	}
+	return config, nil
+}
+
+// checker collects the problems of a config, locating them in the parsed YAML document.
+type checker struct {
+	root     *yaml.Node
+	problems []Problem
+}
+
+// path is a location in the config, made of mapping keys and sequence indices.
+type path []interface{}
+
+func (p path) String() string {
+	var b strings.Builder
+	for _, elem := range p {
+		switch elem := elem.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", elem)
+		default:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			fmt.Fprint(&b, elem)
+		}
+	}
+	return b.String()
+}
+
+func (p path) with(elems ...interface{}) path {
+	return append(append(path{}, p...), elems...)
+}
+
+// line returns the line of the deepest node along p, so missing fields are reported at their parent.
+func (c *checker) line(p path) int {
+	if c.root == nil {
+		return 0
+	}
+	if len(c.root.Content) == 0 {
+		return 1
+	}
+	node := c.root.Content[0]
+	line := node.Line
+	for _, elem := range p {
+		var next *yaml.Node
+		switch elem := elem.(type) {
+		case int:
+			if node.Kind == yaml.SequenceNode && elem < len(node.Content) {
+				next = node.Content[elem]
+			}
+		case string:
+			if node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i].Value == elem {
+						next = node.Content[i+1]
+						break
+					}
+				}
+			}
+		}
+		if next == nil {
+			break
+		}
+		node, line = next, next.Line
+	}
+	return line
+}
+
+func (c *checker) addf(p path, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{
+		Line:    c.line(p),
+		Path:    p.String(),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkKnownFields reports the mapping keys that don't match a yaml tag of the struct they are decoded into.
+func (c *checker) checkKnownFields(node *yaml.Node, t reflect.Type, p path) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				c.problems = append(c.problems, Problem{
+					Line:    key.Line,
+					Path:    p.with(key.Value).String(),
+					Message: "unknown field",
+				})
+				continue
+			}
+			c.checkKnownFields(node.Content[i+1], fieldType, p.with(key.Value))
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			c.checkKnownFields(node.Content[i+1], t.Elem(), p.with(node.Content[i].Value))
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, elem := range node.Content {
+			c.checkKnownFields(elem, t.Elem(), p.with(i))
+		}
+	}
+}
+
+func (c *checker) check(config *Config) {
+	general := path{"general"}
+	if config.General.EthProviderURL == "" {
+		c.addf(general.with("eth_provider_url"), "required")
+	} else if strings.Contains(config.General.EthProviderURL, "://") {
+		// anything else is the path of the node's IPC socket
+		c.checkURL(general.with("eth_provider_url"), config.General.EthProviderURL, "http", "https", "ws", "wss")
+	}
+	if config.General.EthBlockchainName == "" {
+		c.addf(general.with("eth_blockchain_name"), "required")
+	}
+	if config.General.ServerURL == "" {
+		c.addf(general.with("server_url"), "required")
+	}
+	if config.General.EthCacheTTL < 0 {
+		c.addf(general.with("eth_cache_ttl"), "must not be negative")
+	}
+	if config.General.BeaconProviderURL != "" {
+		c.checkURL(general.with("beacon_provider_url"), config.General.BeaconProviderURL, "http", "https")
+	}
+	if _, err := units.Parse(config.General.BalanceUnit, units.Ether); err != nil {
+		c.addf(general.with("balance_unit"), "%v", err)
+	}
+	if _, err := units.Parse(config.General.GasPriceUnit, units.Gwei); err != nil {
+		c.addf(general.with("gas_price_unit"), "%v", err)
+	}
+	switch config.General.EventBlockTag {
+	case "", "latest":
+	case "safe", "finalized":
+		if config.General.EventConfirmations > 0 {
+			c.addf(general.with("event_confirmations"), "can't be set along a %s event_block_tag", config.General.EventBlockTag)
+		}
+	default:
+		c.addf(general.with("event_block_tag"), "must be latest, safe or finalized, got %q", config.General.EventBlockTag)
+	}
+	switch config.General.L2Type {
+	case "", "optimism", "arbitrum":
+	default:
+		c.addf(general.with("l2_type"), "must be optimism or arbitrum, got %q", config.General.L2Type)
+	}
+	if config.General.L2RollupURL != "" {
+		if config.General.L2Type != "optimism" {
+			c.addf(general.with("l2_rollup_url"), "is only used with the optimism l2_type")
+		}
+		c.checkURL(general.with("l2_rollup_url"), config.General.L2RollupURL, "http", "https", "ws", "wss")
+	}
+
+	providers := path{"providers"}
+	c.checkProvider(providers.with("eth"), config.Providers.Eth, config.General.EthProviderURL)
+	c.checkProvider(providers.with("beacon"), config.Providers.Beacon, config.General.BeaconProviderURL)
+	c.checkProvider(providers.with("l2_rollup"), config.Providers.L2Rollup, config.General.L2RollupURL)
+
+	for i, source := range config.TargetSources {
+		c.checkTargetSource(path{"target_sources", i}, source, config.General.EthChainID)
+	}
+
+	c.checkCollectors(config)
+	c.checkBalanceUnits(config)
+	c.checkTargets(&config.Target, config.General.BeaconProviderURL != "")
+}
+
+func (c *checker) checkTargetSource(p path, source TargetSource, chainID uint64) {
+	switch source.Type {
+	case "file":
+		if source.Directory == "" {
+			c.addf(p.with("directory"), "required for a file source")
+		}
+	case "http":
+		c.checkSourceURL(p, source.URL)
+	case "token_list":
+		c.checkSourceURL(p, source.URL)
+		if source.ChainID == 0 && chainID == 0 {
+			c.addf(p.with("chain_id"), "required for a token_list source when general.eth_chain_id isn't set")
+		}
+	default:
+		c.addf(p.with("type"), "must be file, http or token_list, got %q", source.Type)
+	}
+	if source.RefreshInterval < 0 {
+		c.addf(p.with("refresh_interval"), "must not be negative")
+	}
+}
+
+func (c *checker) checkProvider(p path, provider ProviderConfig, providerURL string) {
+	var auth []string
+	if provider.BasicAuth != nil {
+		auth = append(auth, "basic_auth")
+		if provider.BasicAuth.Username == "" {
+			c.addf(p.with("basic_auth", "username"), "required")
+		}
+		if provider.BasicAuth.Password != "" && provider.BasicAuth.PasswordFile != "" {
+			c.addf(p.with("basic_auth", "password_file"), "can't be set along password")
+		}
+	}
+	if provider.BearerToken != "" {
+		auth = append(auth, "bearer_token")
+	}
+	if provider.BearerTokenFile != "" {
+		auth = append(auth, "bearer_token_file")
+	}
+	if provider.JWTSecretFile != "" {
+		auth = append(auth, "jwt_secret_file")
+	}
+	if len(auth) > 1 {
+		c.addf(p.with(auth[1]), "can't be set along %s", auth[0])
+	}
+
+	tls := p.with("tls_config")
+	if (provider.TLSConfig.CertFile == "") != (provider.TLSConfig.KeyFile == "") {
+		c.addf(tls, "cert_file and key_file must be set together")
+	}
+	if provider.ProxyURL != "" {
+		c.checkURL(p.with("proxy_url"), provider.ProxyURL, "http", "https", "socks5")
+	}
+	for _, field := range []struct {
+		name  string
+		value int64
+	}{
+		{"timeout", int64(provider.Timeout)},
+		{"dial_timeout", int64(provider.DialTimeout)},
+		{"max_idle_conns", int64(provider.MaxIdleConns)},
+		{"max_conns_per_host", int64(provider.MaxConnsPerHost)},
+		{"idle_conn_timeout", int64(provider.IdleConnTimeout)},
+	} {
+		if field.value < 0 {
+			c.addf(p.with(field.name), "must not be negative")
+		}
+	}
+
+	rateLimit := p.with("rate_limit")
+	if provider.RateLimit.RequestsPerSecond < 0 {
+		c.addf(rateLimit.with("requests_per_second"), "must not be negative")
+	}
+	if provider.RateLimit.Burst < 0 {
+		c.addf(rateLimit.with("burst"), "must not be negative")
+	}
+	for _, method := range sortedKeys(provider.RateLimit.MethodWeights) {
+		if provider.RateLimit.MethodWeights[method] <= 0 {
+			c.addf(rateLimit.with("method_weights", method), "must be positive")
+		}
+	}
+	retry := p.with("retry")
+	for _, field := range []struct {
+		name  string
+		value int64
+	}{
+		{"max_retries", int64(provider.Retry.MaxRetries)},
+		{"initial_backoff", int64(provider.Retry.InitialBackoff)},
+		{"max_backoff", int64(provider.Retry.MaxBackoff)},
+	} {
+		if field.value < 0 {
+			c.addf(retry.with(field.name), "must not be negative")
+		}
+	}
+	if provider.Retry.MaxBackoff > 0 && provider.Retry.InitialBackoff > provider.Retry.MaxBackoff {
+		c.addf(retry.with("max_backoff"), "must not be less than initial_backoff")
+	}
+
+	if providerURL != "" && !reflect.DeepEqual(provider, ProviderConfig{}) {
+		if u, err := url.Parse(providerURL); err == nil && u.Scheme != "http" && u.Scheme != "https" {
+			c.addf(p, "only applies to http and https providers")
+		}
+	}
+}
+
+func (c *checker) checkSourceURL(p path, value string) {
+	if value == "" {
+		c.addf(p.with("url"), "required for an http or token_list source")
+		return
+	}
+	c.checkURL(p.with("url"), value, "http", "https")
+}
+
+func (c *checker) checkTargets(targets *Targets, hasBeacon bool) {
+	c.validateERC20(targets.ERC20)
+	c.validateWallets(targets.Wallets)
+	c.validateValidators(targets.Validators, hasBeacon)
+}
+
+// ValidateTargets checks targets that don't come from the config file, such as discovered ones, reporting every
+// problem found in a ValidationError for source.
+func ValidateTargets(source string, targets *Targets) error {
+	c := &checker{}
+	// whether validators can be monitored depends on the config, and is checked when applying targets
+	c.checkTargets(targets, true)
+	if len(c.problems) > 0 {
+		return &ValidationError{File: source, Problems: c.problems}
+	}
+	return nil
+}
+
+func (c *checker) validateERC20(targets []ERC20Target) {
+	names, addresses := map[string]int{}, map[common.Address]int{}
+	for i, target := range targets {
+		p := path{"targets", "erc20", i}
+		c.checkName(p, target.Name, names, i)
+		if address, ok := c.checkAddress(p.with("contract"), target.ContractAddr); ok {
+			if first, ok := addresses[address]; ok {
+				c.addf(p.with("contract"), "duplicate of targets.erc20[%d]", first)
+			} else {
+				addresses[address] = i
+			}
+		}
+	}
+}
+
+func (c *checker) validateWallets(targets []WalletTarget) {
+	names, addresses := map[string]int{}, map[common.Address]int{}
+	for i, target := range targets {
+		p := path{"targets", "wallets", i}
+		c.checkName(p, target.Name, names, i)
+		if address, ok := c.checkAddress(p.with("address"), target.Addr); ok {
+			if first, ok := addresses[address]; ok {
+				c.addf(p.with("address"), "duplicate of targets.wallets[%d]", first)
+			} else {
+				addresses[address] = i
+			}
+		}
+		if target.MinBalance != nil && *target.MinBalance < 0 {
+			c.addf(p.with("min_balance"), "must not be negative")
+		}
+		if target.MaxBalance != nil && *target.MaxBalance < 0 {
+			c.addf(p.with("max_balance"), "must not be negative")
+		}
+		if target.MinBalance != nil && target.MaxBalance != nil && *target.MinBalance > *target.MaxBalance {
+			c.addf(p.with("min_balance"), "must not be greater than max_balance")
+		}
+	}
+}
+
+func (c *checker) validateValidators(targets []ValidatorTarget, hasBeacon bool) {
+	if len(targets) > 0 && !hasBeacon {
+		c.addf(path{"targets", "validators"}, "general.beacon_provider_url must be configured to monitor validators")
+	}
+	names, ids := map[string]int{}, map[string]int{}
+	for i, target := range targets {
+		p := path{"targets", "validators", i}
+		c.checkName(p, target.Name, names, i)
+		switch {
+		case target.Index == nil && target.Pubkey == "":
+			c.addf(p, "one of index or pubkey is required")
+			continue
+		case target.Index != nil && target.Pubkey != "":
+			c.addf(p.with("pubkey"), "can't be set along index")
+			continue
+		case target.Index == nil && !pubkeyPattern.MatchString(target.Pubkey):
+			c.addf(p.with("pubkey"), "must be a 0x-prefixed 48 bytes hex string")
+			continue
+		}
+		id := strings.ToLower(target.ID())
+		if first, ok := ids[id]; ok {
+			c.addf(p, "duplicate of targets.validators[%d]", first)
+		} else {
+			ids[id] = i
+		}
+	}
+}
+
+// checkName reports a missing name, or one already used by the target at index names[name].
+func (c *checker) checkName(p path, name string, names map[string]int, i int) {
+	if name == "" {
+		c.addf(p.with("name"), "required")
+		return
+	}
+	if first, ok := names[name]; ok {
+		c.addf(p.with("name"), "duplicate of %s", p[:len(p)-1].with(first))
+		return
+	}
+	names[name] = i
+}
+
+// checkAddress reports an invalid address, or one that is mixed-case but fails the EIP-55 checksum. All-lowercase
+// and all-uppercase addresses carry no checksum and are accepted.
+func (c *checker) checkAddress(p path, address string) (common.Address, bool) {
+	switch {
+	case address == "":
+		c.addf(p, "required")
+		return common.Address{}, false
+	case !common.IsHexAddress(address) || !strings.HasPrefix(address, "0x"):
+		c.addf(p, "%q is not a 0x-prefixed 20 bytes hex address", address)
+		return common.Address{}, false
+	}
+	parsed := common.HexToAddress(address)
+	digits := address[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && address != parsed.Hex() {
+		c.addf(p, "%q fails the EIP-55 checksum, expected %s", address, parsed.Hex())
+		return common.Address{}, false
+	}
+	return parsed, true
+}
+
+// checkURL reports a value that isn't an absolute URL with one of the given schemes. Only the scheme and host of the
+// value are reported, since providers take API keys as credentials, path or query.
+func (c *checker) checkURL(p path, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			// the error quotes the whole URL
+			err = urlErr.Err
+		}
+		c.addf(p, "invalid URL: %v", err)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme && u.Host != "" {
+			return
+		}
+	}
+	if u.Host == "" {
+		c.addf(p, "must be an absolute URL with one of the schemes %s", strings.Join(schemes, ", "))
+		return
+	}
+	c.addf(p, "%q must be a URL with one of the schemes %s", u.Scheme+"://"+u.Host, strings.Join(schemes, ", "))
+}
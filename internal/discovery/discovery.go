@@ -0,0 +1,176 @@
+// Package discovery loads targets from sources other than the config file, and keeps them up to date.
+package discovery
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// DefaultRefreshInterval is how often sources are refreshed when they don't set a refresh_interval.
+const DefaultRefreshInterval = 5 * time.Minute
+
+// RefreshTimeout bounds each refresh of a source, so a hung source neither blocks startup nor its later refreshes.
+const RefreshTimeout = 30 * time.Second
+
+type source struct {
+	name     string
+	source   Source
+	interval time.Duration
+}
+
+// Discovery periodically refreshes targets from a set of sources. A source failing to refresh, or returning invalid
+// targets, keeps its previous targets.
+type Discovery struct {
+	sources []source
+	mutex   sync.Mutex
+	// targets holds the latest valid targets of each source.
+	targets []*config.Targets
+}
+
+// New creates the sources configured in the target_sources section. Token lists default to chainID.
+func New(sources []config.TargetSource, chainID uint64, client *http.Client) (*Discovery, error) {
+	d := &Discovery{targets: make([]*config.Targets, len(sources))}
+	for _, s := range sources {
+		interval := s.RefreshInterval
+		if interval == 0 {
+			interval = DefaultRefreshInterval
+		}
+		switch s.Type {
+		case "file":
+			d.sources = append(d.sources, source{"file source " + s.Directory, NewFileSource(s.Directory), interval})
+		case "http":
+			d.sources = append(d.sources, source{"http source " + s.URL, NewHTTPSource(s.URL, client), interval})
+		case "token_list":
+			listChainID := s.ChainID
+			if listChainID == 0 {
+				listChainID = chainID
+			}
+			d.sources = append(d.sources, source{"token list " + s.URL, NewTokenListSource(s.URL, listChainID, client), interval})
+		default:
+			return nil, errors.Errorf("unknown target source type %q", s.Type)
+		}
+	}
+	return d, nil
+}
+
+// refresh fetches the targets of the i-th source, and returns whether they changed.
+func (d *Discovery) refresh(ctx context.Context, i int) (bool, error) {
+	s := d.sources[i]
+	ctx, cancel := context.WithTimeout(ctx, RefreshTimeout)
+	defer cancel()
+	targets, err := s.source.Targets(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := config.ValidateTargets(s.name, targets); err != nil {
+		return false, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if reflect.DeepEqual(d.targets[i], targets) {
+		return false, nil
+	}
+	d.targets[i] = targets
+	return true, nil
+}
+
+// Refresh fetches the targets of every source once, logging the sources that fail.
+func (d *Discovery) Refresh(ctx context.Context) {
+	for i, s := range d.sources {
+		if _, err := d.refresh(ctx, i); err != nil {
+			log.Printf("Failed to refresh targets from %s: %v", s.name, err)
+		}
+	}
+}
+
+// Targets returns the union of the latest targets of every source.
+func (d *Discovery) Targets() config.Targets {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var targets []config.Targets
+	for _, t := range d.targets {
+		if t != nil {
+			targets = append(targets, *t)
+		}
+	}
+	return Merge(targets...)
+}
+
+// Run refreshes every source on its own interval until ctx is done, calling onChange with the union of the targets
+// of every source whenever one of them changes.
+func (d *Discovery) Run(ctx context.Context, onChange func(config.Targets)) {
+	wg := sync.WaitGroup{}
+	for i := range d.sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := d.sources[i]
+			ticker := time.NewTicker(s.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				changed, err := d.refresh(ctx, i)
+				if err != nil {
+					log.Printf("Failed to refresh targets from %s: %v", s.name, err)
+					continue
+				}
+				if changed {
+					log.Printf("Targets from %s changed\n", s.name)
+					onChange(d.Targets())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Merge returns the union of several targets. Targets with the address or name of an earlier one are left out, so
+// earlier targets take precedence.
+func Merge(targets ...config.Targets) config.Targets {
+	var merged config.Targets
+	erc20Names, erc20Addresses := map[string]bool{}, map[common.Address]bool{}
+	walletNames, walletAddresses := map[string]bool{}, map[common.Address]bool{}
+	validatorNames, validatorIDs := map[string]bool{}, map[string]bool{}
+	for _, t := range targets {
+		for _, target := range t.ERC20 {
+			address := common.HexToAddress(target.ContractAddr)
+			if erc20Names[target.Name] || erc20Addresses[address] {
+				continue
+			}
+			erc20Names[target.Name], erc20Addresses[address] = true, true
+			merged.ERC20 = append(merged.ERC20, target)
+		}
+		for _, target := range t.Wallets {
+			address := common.HexToAddress(target.Addr)
+			if walletNames[target.Name] || walletAddresses[address] {
+				continue
+			}
+			walletNames[target.Name], walletAddresses[address] = true, true
+			merged.Wallets = append(merged.Wallets, target)
+		}
+		for _, target := range t.Validators {
+			id := strings.ToLower(target.ID())
+			if validatorNames[target.Name] || validatorIDs[id] {
+				continue
+			}
+			validatorNames[target.Name], validatorIDs[id] = true, true
+			merged.Validators = append(merged.Validators, target)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+func TestMerge(t *testing.T) {
+	static := config.Targets{
+		ERC20: []config.ERC20Target{
+			{Name: "USDT", ContractAddr: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+		},
+		Validators: []config.ValidatorTarget{
+			{Name: "validator 1", Pubkey: "0xA1D1AD07"},
+		},
+	}
+	discovered := config.Targets{
+		ERC20: []config.ERC20Target{
+			{Name: "Tether USD", ContractAddr: "0xdac17f958d2ee523a2206206994597c13d831ec7"},
+			{Name: "USDT", ContractAddr: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+			{Name: "USD Coin", ContractAddr: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+		},
+		Validators: []config.ValidatorTarget{
+			{Name: "validator 2", Pubkey: "0xa1d1ad07"},
+		},
+	}
+
+	expected := config.Targets{
+		ERC20: []config.ERC20Target{
+			{Name: "USDT", ContractAddr: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+			{Name: "USD Coin", ContractAddr: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+		},
+		Validators: []config.ValidatorTarget{
+			{Name: "validator 1", Pubkey: "0xA1D1AD07"},
+		},
+	}
+	if got := Merge(static, discovered); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %#v, want %#v", got, expected)
+	}
+}
+
+func TestDiscoveryRefresh(t *testing.T) {
+	dir := t.TempDir()
+	d, err := New([]config.TargetSource{{Type: "file", Directory: dir}}, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "targets.yaml"), `
+erc20:
+  - name: "USDT"
+    contract: "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+`)
+	if changed, err := d.refresh(context.Background(), 0); err != nil || !changed {
+		t.Fatalf("got changed %v and error %v, want a change", changed, err)
+	}
+	if changed, err := d.refresh(context.Background(), 0); err != nil || changed {
+		t.Fatalf("got changed %v and error %v, want no change", changed, err)
+	}
+
+	// invalid targets are rejected, keeping the previous ones
+	writeFile(t, filepath.Join(dir, "targets.yaml"), `
+erc20:
+  - name: "USDT"
+    contract: "0x123123"
+`)
+	if _, err := d.refresh(context.Background(), 0); err == nil {
+		t.Fatalf("expected a validation error")
+	}
+
+	expected := config.Targets{
+		ERC20: []config.ERC20Target{
+			{Name: "USDT", ContractAddr: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+		},
+	}
+	if got := d.Targets(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %#v, want %#v", got, expected)
+	}
+}
+
+func TestNewUnknownSource(t *testing.T) {
+	if _, err := New([]config.TargetSource{{Type: "s3"}}, 1, nil); err == nil {
+		t.Fatalf("expected an unknown source error")
+	}
+}
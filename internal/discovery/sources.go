@@ -0,0 +1,154 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Source returns the targets currently published by an external source.
+type Source interface {
+	Targets(ctx context.Context) (*config.Targets, error)
+}
+
+// FileSource reads targets from the YAML or JSON files of a directory, each in the format of the targets section.
+// The directory is read again on every refresh, rather than watched, and unknown fields are rejected like in the
+// config file.
+type FileSource struct {
+	dir string
+}
+
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{dir: dir}
+}
+
+func (s *FileSource) Targets(ctx context.Context) (*config.Targets, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list target files in %s", s.dir)
+	}
+	var names []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var targets []config.Targets
+	for _, name := range names {
+		path := filepath.Join(s.dir, name)
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read target file %s", path)
+		}
+		var fileTargets config.Targets
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&fileTargets); err != nil && err != io.EOF {
+			return nil, errors.Wrapf(err, "failed to parse target file %s", path)
+		}
+		targets = append(targets, fileTargets)
+	}
+	merged := Merge(targets...)
+	return &merged, nil
+}
+
+// HTTPSource fetches targets from an HTTP endpoint serving them as JSON, in the format of the targets section.
+type HTTPSource struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSource(url string, client *http.Client) *HTTPSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSource{url: url, client: client}
+}
+
+func (s *HTTPSource) Targets(ctx context.Context) (*config.Targets, error) {
+	var targets config.Targets
+	if err := getJSON(ctx, s.client, s.url, &targets); err != nil {
+		return nil, err
+	}
+	return &targets, nil
+}
+
+// tokenList is the standard token list format, see https://github.com/Uniswap/token-lists.
+type tokenList struct {
+	Name   string `json:"name"`
+	Tokens []struct {
+		ChainID uint64 `json:"chainId"`
+		Address string `json:"address"`
+		Name    string `json:"name"`
+		Symbol  string `json:"symbol"`
+	} `json:"tokens"`
+}
+
+// TokenListSource fetches ERC-20 targets from a token list, keeping the tokens of a single chain.
+type TokenListSource struct {
+	url     string
+	chainID uint64
+	client  *http.Client
+}
+
+func NewTokenListSource(url string, chainID uint64, client *http.Client) *TokenListSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &TokenListSource{url: url, chainID: chainID, client: client}
+}
+
+func (s *TokenListSource) Targets(ctx context.Context) (*config.Targets, error) {
+	var list tokenList
+	if err := getJSON(ctx, s.client, s.url, &list); err != nil {
+		return nil, err
+	}
+
+	targets := &config.Targets{}
+	for _, token := range list.Tokens {
+		if token.ChainID != s.chainID {
+			continue
+		}
+		targets.ERC20 = append(targets.ERC20, config.ERC20Target{
+			Name:         token.Name,
+			ContractAddr: token.Address,
+		})
+	}
+	return targets, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch targets from %s", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to fetch targets from %s: unexpected status %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "failed to decode targets from %s", url)
+	}
+	return nil
+}
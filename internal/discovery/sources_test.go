@@ -0,0 +1,142 @@
+package discovery
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// newMockSourceServer serves body with the given status for every request.
+func newMockSourceServer(t *testing.T, status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	}))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("could not write %s: %v", path, err)
+	}
+}
+
+func TestFileSourceTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b.yaml"), `
+erc20:
+  - name: "USDC"
+    contract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+wallets:
+  - name: "hot wallet"
+    address: "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+`)
+	writeFile(t, filepath.Join(dir, "a.json"), `{"erc20": [{"name": "USDT", "contract": "0xdAC17F958D2ee523a2206206994597C13D831ec7"}]}`)
+	writeFile(t, filepath.Join(dir, "README.md"), "not a target file")
+
+	targets, err := NewFileSource(dir).Targets(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &config.Targets{
+		ERC20: []config.ERC20Target{
+			{Name: "USDT", ContractAddr: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+			{Name: "USDC", ContractAddr: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+		},
+		Wallets: []config.WalletTarget{
+			{Name: "hot wallet", Addr: "0x742d35cc6634c0532925a3b844bc454e4438f44e"},
+		},
+	}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("got %#v, want %#v", targets, expected)
+	}
+}
+
+func TestFileSourceTargetsError(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "targets.yaml"), "erc20: {")
+
+	if _, err := NewFileSource(dir).Targets(context.Background()); err == nil {
+		t.Fatalf("expected a parse error")
+	}
+	if _, err := NewFileSource(filepath.Join(dir, "missing")).Targets(context.Background()); err == nil {
+		t.Fatalf("expected a missing directory error")
+	}
+}
+
+func TestHTTPSourceTargets(t *testing.T) {
+	server := newMockSourceServer(t, http.StatusOK, `{"wallets": [{"name": "hot wallet", "address": "0x742d35cc6634c0532925a3b844bc454e4438f44e"}]}`)
+	defer server.Close()
+
+	targets, err := NewHTTPSource(server.URL, nil).Targets(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &config.Targets{
+		Wallets: []config.WalletTarget{
+			{Name: "hot wallet", Addr: "0x742d35cc6634c0532925a3b844bc454e4438f44e"},
+		},
+	}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("got %#v, want %#v", targets, expected)
+	}
+}
+
+func TestHTTPSourceTargetsError(t *testing.T) {
+	server := newMockSourceServer(t, http.StatusInternalServerError, `{}`)
+	defer server.Close()
+
+	if _, err := NewHTTPSource(server.URL, nil).Targets(context.Background()); err == nil {
+		t.Fatalf("expected an unexpected status error")
+	}
+}
+
+func TestTokenListSourceTargets(t *testing.T) {
+	server := newMockSourceServer(t, http.StatusOK, `{
+		"name": "Test List",
+		"tokens": [
+			{"chainId": 1, "address": "0xdAC17F958D2ee523a2206206994597C13D831ec7", "name": "Tether USD", "symbol": "USDT", "decimals": 6},
+			{"chainId": 10, "address": "0x94b008aA00579c1307B0EF2c499aD98a8ce58e58", "name": "Tether USD", "symbol": "USDT", "decimals": 6},
+			{"chainId": 1, "address": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "name": "USD Coin", "symbol": "USDC", "decimals": 6}
+		]
+	}`)
+	defer server.Close()
+
+	targets, err := NewTokenListSource(server.URL, 1, nil).Targets(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &config.Targets{
+		ERC20: []config.ERC20Target{
+			{Name: "Tether USD", ContractAddr: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+			{Name: "USD Coin", ContractAddr: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+		},
+	}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("got %#v, want %#v", targets, expected)
+	}
+}
+
+func TestFileSourceTargetsUnknownField(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "targets.yaml"), `
+wallets:
+  - name: "hot wallet"
+    addr: "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+`)
+	writeFile(t, filepath.Join(dir, "empty.yaml"), "")
+
+	if _, err := NewFileSource(dir).Targets(context.Background()); err == nil {
+		t.Fatalf("expected an unknown field error")
+	}
+}
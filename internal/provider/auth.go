@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// jwtRefreshInterval is how long a JWT is reused. Execution clients reject tokens issued more than 60 seconds away
+// from their clock.
+const jwtRefreshInterval = 30 * time.Second
+
+// jwtHeader is the encoded {"alg":"HS256","typ":"JWT"} header.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// authTransport adds the headers and the Authorization header of a provider config to every request.
+type authTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+	// authorization returns the value of the Authorization header, if any.
+	authorization func() (string, error)
+}
+
+func newAuthTransport(base http.RoundTripper, cfg config.ProviderConfig) (*authTransport, error) {
+	t := &authTransport{base: base, headers: cfg.Headers}
+	switch {
+	case cfg.BasicAuth != nil:
+		auth := *cfg.BasicAuth
+		t.authorization = func() (string, error) {
+			password := auth.Password
+			if auth.PasswordFile != "" {
+				var err error
+				if password, err = readSecret(auth.PasswordFile); err != nil {
+					return "", err
+				}
+			}
+			return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+password)), nil
+		}
+	case cfg.BearerToken != "":
+		t.authorization = func() (string, error) {
+			return "Bearer " + cfg.BearerToken, nil
+		}
+	case cfg.BearerTokenFile != "":
+		t.authorization = func() (string, error) {
+			token, err := readSecret(cfg.BearerTokenFile)
+			if err != nil {
+				return "", err
+			}
+			return "Bearer " + token, nil
+		}
+	case cfg.JWTSecretFile != "":
+		jwt := &jwtSource{secretFile: cfg.JWTSecretFile, now: time.Now}
+		// fail early on a missing or malformed secret
+		if _, err := jwt.token(); err != nil {
+			return nil, err
+		}
+		t.authorization = func() (string, error) {
+			token, err := jwt.token()
+			if err != nil {
+				return "", err
+			}
+			return "Bearer " + token, nil
+		}
+	}
+	return t, nil
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// requests must not be modified, see http.RoundTripper
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	if t.authorization != nil {
+		authorization, err := t.authorization()
+		if err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+		req.Header.Set("Authorization", authorization)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// jwtSource issues the HS256 tokens of the engine API authentication, only holding an iat claim. A new token is
+// issued every jwtRefreshInterval, re-reading the secret so it can be rotated.
+type jwtSource struct {
+	secretFile string
+	now        func() time.Time
+
+	mutex    sync.Mutex
+	issuedAt time.Time
+	cached   string
+}
+
+func (s *jwtSource) token() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	if s.cached != "" && now.Sub(s.issuedAt) < jwtRefreshInterval {
+		return s.cached, nil
+	}
+
+	secret, err := readJWTSecret(s.secretFile)
+	if err != nil {
+		return "", err
+	}
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"iat":%d}`, now.Unix())))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(jwtHeader + "." + claims))
+	s.cached = jwtHeader + "." + claims + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	s.issuedAt = now
+	return s.cached, nil
+}
+
+// readJWTSecret reads a 32 bytes hex-encoded secret, as generated for execution and consensus clients.
+func readJWTSecret(path string) ([]byte, error) {
+	encoded, err := readSecret(path)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil || len(secret) != 32 {
+		return nil, errors.Errorf("invalid JWT secret in %s, expected 32 hex-encoded bytes", path)
+	}
+	return secret, nil
+}
+
+func readSecret(path string) (string, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read provider credentials")
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
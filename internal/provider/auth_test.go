@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+const mockJWTSecret = "0x7365637265747365637265747365637265747365637265747365637265747365"
+
+// newMockRPCHandler answers eth_blockNumber, recording the headers of the last request.
+func newMockRPCHandler(t *testing.T, headers *http.Header) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*headers = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": "0x10"}`)); err != nil {
+			t.Fatalf("could not write a response: %#v", err)
+		}
+	})
+}
+
+func newMockRPCServer(t *testing.T, headers *http.Header) *httptest.Server {
+	return httptest.NewServer(newMockRPCHandler(t, headers))
+}
+
+func writeSecret(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("could not write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestDialSendsProviderConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.ProviderConfig
+		authorization string
+	}{
+		{
+			name:          "basic auth",
+			cfg:           config.ProviderConfig{BasicAuth: &config.BasicAuth{Username: "user", Password: "pass"}},
+			authorization: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:          "basic auth password file",
+			cfg:           config.ProviderConfig{BasicAuth: &config.BasicAuth{Username: "user", PasswordFile: writeSecret(t, "pass\n")}},
+			authorization: "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:          "bearer token",
+			cfg:           config.ProviderConfig{BearerToken: "token"},
+			authorization: "Bearer token",
+		},
+		{
+			name:          "bearer token file",
+			cfg:           config.ProviderConfig{BearerTokenFile: writeSecret(t, "token\n")},
+			authorization: "Bearer token",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var headers http.Header
+			server := newMockRPCServer(t, &headers)
+			defer server.Close()
+
+			test.cfg.Headers = map[string]string{"X-Api-Key": "key"}
+			client, err := Dial(server.URL, test.cfg, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer client.Close()
+
+			var result string
+			if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := headers.Get("X-Api-Key"); got != "key" {
+				t.Fatalf("got X-Api-Key %q, want key", got)
+			}
+			if got := headers.Get("Authorization"); got != test.authorization {
+				t.Fatalf("got Authorization %q, want %q", got, test.authorization)
+			}
+		})
+	}
+}
+
+func TestDialSendsJWT(t *testing.T) {
+	var headers http.Header
+	server := newMockRPCServer(t, &headers)
+	defer server.Close()
+
+	client, err := Dial(server.URL, config.ProviderConfig{JWTSecretFile: writeSecret(t, mockJWTSecret)}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	token := strings.TrimPrefix(headers.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("got token %q, want a JWT", token)
+	}
+	mac := hmac.New(sha256.New, []byte("secretsecretsecretsecretsecretse"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil)); signature != parts[2] {
+		t.Fatalf("got signature %q, want %q", parts[2], signature)
+	}
+}
+
+func TestJWTSourceRefresh(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	source := &jwtSource{secretFile: writeSecret(t, mockJWTSecret), now: func() time.Time { return now }}
+
+	first, err := source.token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"iat":1700000000}`))
+	if !strings.Contains(first, "."+claims+".") {
+		t.Fatalf("got token %q, want claims %q", first, claims)
+	}
+
+	now = now.Add(jwtRefreshInterval - time.Second)
+	if second, _ := source.token(); second != first {
+		t.Fatalf("expected the token to be reused")
+	}
+
+	now = now.Add(time.Second)
+	if third, _ := source.token(); third == first {
+		t.Fatalf("expected a new token")
+	}
+}
+
+func TestNewHTTPClientInvalidJWTSecret(t *testing.T) {
+	if _, err := NewHTTPClient(config.ProviderConfig{JWTSecretFile: writeSecret(t, "0x1234")}, nil); err == nil {
+		t.Fatalf("expected an invalid secret error")
+	}
+}
@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// DefaultMaxBatchSize is below the batch limits of the common providers.
+	DefaultMaxBatchSize = 100
+	// batchWindow is how long a call waits for others to join its batch. Collectors are scraped concurrently, so
+	// the calls of a scrape are issued within it.
+	batchWindow = 10 * time.Millisecond
+)
+
+// Batcher groups the JSON-RPC calls issued within a short window into batch requests of at most maxSize calls, so
+// the calls of a scrape take a few round trips instead of one each.
+type Batcher struct {
+	client  *rpc.Client
+	maxSize int
+	window  time.Duration
+
+	mutex   sync.Mutex
+	pending []*batchedCall
+}
+
+type batchedCall struct {
+	ctx  context.Context
+	elem rpc.BatchElem
+	// raw receives the result of the call, which is only decoded into the result of the caller once done, so a
+	// caller giving up doesn't get its result written after returning.
+	raw  json.RawMessage
+	done chan struct{}
+}
+
+// NewBatcher returns a batcher sending its batches through client. A maxSize of 0 uses DefaultMaxBatchSize, and
+// one of 1 sends every call on its own.
+func NewBatcher(client *rpc.Client, maxSize int) *Batcher {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBatchSize
+	}
+	return &Batcher{client: client, maxSize: maxSize, window: batchWindow}
+}
+
+// CallContext queues a call for the next batch, and waits for its result like rpc.Client.CallContext.
+func (b *Batcher) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if b.maxSize == 1 {
+		return b.client.CallContext(ctx, result, method, args...)
+	}
+
+	call := &batchedCall{
+		ctx:  ctx,
+		done: make(chan struct{}),
+	}
+	call.elem = rpc.BatchElem{Method: method, Args: args, Result: &call.raw}
+	b.mutex.Lock()
+	b.pending = append(b.pending, call)
+	switch {
+	case len(b.pending) >= b.maxSize:
+		go b.send(b.take())
+	case len(b.pending) == 1:
+		time.AfterFunc(b.window, b.flush)
+	}
+	b.mutex.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if call.elem.Error != nil {
+		return call.elem.Error
+	}
+	if result == nil || len(call.raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(call.raw, result)
+}
+
+// take returns the pending calls, emptying the queue. It must be called with the mutex held.
+func (b *Batcher) take() []*batchedCall {
+	calls := b.pending
+	b.pending = nil
+	return calls
+}
+
+func (b *Batcher) flush() {
+	b.mutex.Lock()
+	calls := b.take()
+	b.mutex.Unlock()
+	if len(calls) > 0 {
+		b.send(calls)
+	}
+}
+
+// send sends calls in a batch request, which is cancelled once every call is abandoned.
+func (b *Batcher) send(calls []*batchedCall) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for _, call := range calls {
+			select {
+			case <-call.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+
+	batch := make([]rpc.BatchElem, len(calls))
+	for i, call := range calls {
+		batch[i] = call.elem
+	}
+	err := b.client.BatchCallContext(ctx, batch)
+	for i, call := range calls {
+		call.elem.Error = batch[i].Error
+		if err != nil {
+			call.elem.Error = err
+		}
+		close(call.done)
+	}
+}
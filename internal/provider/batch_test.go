@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// batchRecorder records the number of calls of every request reaching a JSON-RPC server, with 0 for calls sent
+// outside a batch.
+type batchRecorder struct {
+	mutex sync.Mutex
+	sizes []int
+}
+
+func (r *batchRecorder) record(size int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sizes = append(r.sizes, size)
+}
+
+func (r *batchRecorder) requests() []int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]int(nil), r.sizes...)
+}
+
+// newBatchServer serves the mock JSON-RPC handler, recording its requests.
+func newBatchServer(t *testing.T) (*httptest.Server, *batchRecorder) {
+	recorder := &batchRecorder{}
+	handler := newMockJSONRPCHandler(t, http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("could not read the request: %v", err)
+		}
+		calls, batch := parseMessages(body)
+		if batch {
+			recorder.record(len(calls))
+		} else {
+			recorder.record(0)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, recorder
+}
+
+func newTestBatcher(t *testing.T, url string, maxSize int) *Batcher {
+	client, err := rpc.DialHTTP(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(client.Close)
+	batcher := NewBatcher(client, maxSize)
+	// leave time to the calls of the test goroutines to join a batch
+	batcher.window = 100 * time.Millisecond
+	return batcher
+}
+
+// callConcurrently sends the methods concurrently through batcher, and returns their errors.
+func callConcurrently(batcher *Batcher, methods ...string) []error {
+	errs := make([]error, len(methods))
+	wg := sync.WaitGroup{}
+	for i, method := range methods {
+		wg.Add(1)
+		go func(i int, method string) {
+			defer wg.Done()
+			var result hexutil.Uint64
+			errs[i] = batcher.CallContext(context.Background(), &result, method)
+			if errs[i] == nil && result != 0x10 {
+				errs[i] = fmt.Errorf("got %v, want 0x10", result)
+			}
+		}(i, method)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestBatcherGroupsCalls(t *testing.T) {
+	server, recorder := newBatchServer(t)
+	batcher := newTestBatcher(t, server.URL, 0)
+
+	errs := callConcurrently(batcher, "eth_blockNumber", "eth_blockNumber", "eth_hashrate", "eth_blockNumber")
+	for i, err := range errs {
+		if i == 2 {
+			if _, ok := err.(rpc.Error); !ok {
+				t.Fatalf("got %v, want the JSON-RPC error of eth_hashrate", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := recorder.requests(); len(got) != 1 || got[0] != 4 {
+		t.Fatalf("got requests of %v calls, want a single batch of 4", got)
+	}
+}
+
+func TestBatcherMaxSize(t *testing.T) {
+	server, recorder := newBatchServer(t)
+	batcher := newTestBatcher(t, server.URL, 2)
+
+	for _, err := range callConcurrently(batcher, "eth_blockNumber", "eth_blockNumber", "eth_blockNumber", "eth_blockNumber", "eth_blockNumber") {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	total := 0
+	for _, size := range recorder.requests() {
+		if size > 2 {
+			t.Fatalf("got a batch of %d calls, want at most 2", size)
+		}
+		total += size
+	}
+	if total != 5 {
+		t.Fatalf("got %d batched calls, want 5", total)
+	}
+}
+
+func TestBatcherDisabled(t *testing.T) {
+	server, recorder := newBatchServer(t)
+	batcher := newTestBatcher(t, server.URL, 1)
+
+	for _, err := range callConcurrently(batcher, "eth_blockNumber", "eth_blockNumber") {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := recorder.requests(); len(got) != 2 || got[0] != 0 || got[1] != 0 {
+		t.Fatalf("got requests of %v calls, want 2 calls outside a batch", got)
+	}
+}
+
+func TestBatcherContextDone(t *testing.T) {
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer server.Close()
+	defer close(hang)
+	batcher := newTestBatcher(t, server.URL, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var result hexutil.Uint64
+	if err := batcher.CallContext(ctx, &result, "eth_blockNumber"); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want the deadline to be exceeded", err)
+	}
+}
+
+func TestBatcherAbandonedCallResult(t *testing.T) {
+	handler := newMockJSONRPCHandler(t, http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	batcher := newTestBatcher(t, server.URL, 0)
+
+	// the abandoned call shares its batch with a call still waiting for it
+	var abandoned hexutil.Uint64
+	done := make(chan error)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+		defer cancel()
+		done <- batcher.CallContext(ctx, &abandoned, "eth_blockNumber")
+	}()
+	if errs := callConcurrently(batcher, "eth_blockNumber"); errs[0] != nil {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+	if err := <-done; err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want the deadline to be exceeded", err)
+	}
+	if abandoned != 0 {
+		t.Fatalf("got %v written to the result of the abandoned call", abandoned)
+	}
+}
@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/scrape"
+)
+
+// maxBlockEntries bounds the results of calls pinning a block by hash, which are kept until newer ones evict them.
+const maxBlockEntries = 1024
+
+// blockHashMethods take a block hash as their first parameter.
+var blockHashMethods = map[string]bool{
+	"eth_getBlockByHash":                       true,
+	"eth_getBlockTransactionCountByHash":       true,
+	"eth_getUncleCountByBlockHash":             true,
+	"eth_getTransactionByBlockHashAndIndex":    true,
+	"eth_getUncleByBlockHashAndIndex":          true,
+	"debug_traceBlockByHash":                   true,
+	"eth_getHeaderByHash":                      true,
+	"eth_getRawTransactionByBlockHashAndIndex": true,
+}
+
+// stateMethods read the state at the block given by the parameter at their index, which may pin a block by hash as
+// EIP-1898 allows.
+var stateMethods = map[string]int{
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_getStorageAt":        2,
+	"eth_call":                1,
+}
+
+// byHashMethods map the methods taking a block number as their first parameter to the ones taking a block hash.
+var byHashMethods = map[string]string{
+	"eth_getBlockByNumber":                 "eth_getBlockByHash",
+	"eth_getBlockTransactionCountByNumber": "eth_getBlockTransactionCountByHash",
+	"eth_getUncleCountByBlockNumber":       "eth_getUncleCountByBlockHash",
+}
+
+// Caller sends JSON-RPC calls, such as an *rpc.Client or a Batcher.
+type Caller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// Cache shares the results of JSON-RPC calls between the scrapes of concurrent or back-to-back scrapers, keyed by
+// method and parameters, which include the block a call is made at. Identical calls in flight are sent once, and
+// results are kept for ttl, or, for calls pinning a block by hash, until maxBlockEntries newer ones evict them. Errors
+// are never kept.
+//
+// Within a scrape, calls made at the latest block are pinned to the head block, resolved once per scrape, by hash, so
+// every collector of the scrape reads the same block, and their results are kept until the head moves.
+type Cache struct {
+	next Caller
+	ttl  time.Duration
+	now  func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+	// blockKeys holds the keys of the entries pinning a block by hash, oldest first.
+	blockKeys []string
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	done   chan struct{}
+	result json.RawMessage
+	err    error
+	// expires is set once the call completes, and stays zero for entries pinning a block by hash.
+	expires time.Time
+}
+
+// NewCache returns a cache in front of next. A ttl of 0 only shares the calls in flight.
+func NewCache(next Caller, ttl time.Duration) *Cache {
+	return &Cache{next: next, ttl: ttl, now: time.Now, entries: map[string]*cacheEntry{}}
+}
+
+// CallContext returns the cached result of a call, or sends it through the next caller like rpc.Client.CallContext.
+func (c *Cache) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if index, ok := latestBlockParam(method, args); ok && scrape.Scoped(ctx) {
+		if head, ok := c.head(ctx); ok {
+			if method == "eth_getBlockByNumber" && len(args) == 2 && args[1] == false {
+				// the head block itself
+				if result == nil {
+					return nil
+				}
+				return json.Unmarshal(head.raw, result)
+			}
+			method, args = head.pin(method, args, index)
+		}
+	}
+	return c.call(ctx, result, method, args...)
+}
+
+// headKey is the key of the head block of a scrape, resolved through cache.
+type headKey struct {
+	cache *Cache
+}
+
+// headBlock is the latest block when a scrape resolved it.
+type headBlock struct {
+	Hash common.Hash `json:"hash"`
+	raw  json.RawMessage
+}
+
+// head returns the head block of the scrape of ctx, and false when it can't be resolved, leaving calls unpinned.
+func (c *Cache) head(ctx context.Context) (*headBlock, bool) {
+	value, err := scrape.Once(ctx, headKey{c}, func() (interface{}, error) {
+		var raw json.RawMessage
+		if err := c.call(ctx, &raw, "eth_getBlockByNumber", "latest", false); err != nil {
+			return nil, err
+		}
+		head := &headBlock{raw: raw}
+		if err := json.Unmarshal(raw, head); err != nil {
+			return nil, err
+		}
+		if head.Hash == (common.Hash{}) {
+			return nil, errors.New("no latest block")
+		}
+		return head, nil
+	})
+	if err != nil {
+		return nil, false
+	}
+	return value.(*headBlock), true
+}
+
+// pin returns a call made at the latest block, whose block parameter is at index, made at head by hash instead.
+func (h *headBlock) pin(method string, args []interface{}, index int) (string, []interface{}) {
+	pinned := append([]interface{}{}, args...)
+	if byHash, ok := byHashMethods[method]; ok {
+		pinned[index] = h.Hash
+		return byHash, pinned
+	}
+	pinned[index] = rpc.BlockNumberOrHashWithHash(h.Hash, false)
+	return method, pinned
+}
+
+// latestBlockParam returns the index of the block parameter of a call made at the latest block, and false for other
+// calls.
+func latestBlockParam(method string, args []interface{}) (int, bool) {
+	index, ok := stateMethods[method]
+	if _, byNumber := byHashMethods[method]; byNumber {
+		index, ok = 0, true
+	}
+	if !ok || index >= len(args) {
+		return 0, false
+	}
+	tag, ok := args[index].(string)
+	return index, ok && tag == "latest"
+}
+
+// call returns the cached result of a call, or sends it through the next caller.
+func (c *Cache) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if args == nil {
+		args = []interface{}{}
+	}
+	params, err := json.Marshal(args)
+	if err != nil {
+		return c.next.CallContext(ctx, result, method, args...)
+	}
+	key := method + string(params)
+
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	if ok && !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		entry = &cacheEntry{done: make(chan struct{})}
+		c.entries[key] = entry
+		c.sweep()
+	}
+	c.mutex.Unlock()
+
+	if !ok {
+		c.fetch(ctx, key, entry, pinsBlockHash(method, params), method, args)
+	}
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if ok && entry.err != nil && ctx.Err() == nil && (errors.Is(entry.err, context.Canceled) || errors.Is(entry.err, context.DeadlineExceeded)) {
+		// the call was abandoned by the scrape that sent it
+		return c.call(ctx, result, method, args...)
+	}
+	if entry.err != nil {
+		return entry.err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(entry.result, result)
+}
+
+// fetch sends the call of entry, and keeps its result, unless it failed.
+func (c *Cache) fetch(ctx context.Context, key string, entry *cacheEntry, pinned bool, method string, args []interface{}) {
+	var result json.RawMessage
+	err := c.next.CallContext(ctx, &result, method, args...)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry.result, entry.err = result, err
+	switch {
+	case err != nil || (!pinned && c.ttl <= 0):
+		delete(c.entries, key)
+	case pinned:
+		c.blockKeys = append(c.blockKeys, key)
+		if len(c.blockKeys) > maxBlockEntries {
+			delete(c.entries, c.blockKeys[0])
+			c.blockKeys = c.blockKeys[1:]
+		}
+	default:
+		entry.expires = c.now().Add(c.ttl)
+	}
+	close(entry.done)
+}
+
+// sweep drops the expired entries, at most once per ttl so the lookups stay cheap. It must be called with the mutex
+// held.
+func (c *Cache) sweep() {
+	now := c.now()
+	if c.ttl <= 0 || now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// pinsBlockHash returns whether a call is made at a block identified by its hash, as the methods taking a block hash
+// or the EIP-1898 block parameter {"blockHash": ...} do, so its result never changes.
+func pinsBlockHash(method string, params []byte) bool {
+	if blockHashMethods[method] {
+		return true
+	}
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil {
+		return false
+	}
+	for _, arg := range args {
+		var block struct {
+			BlockHash *common.Hash `json:"blockHash"`
+		}
+		if json.Unmarshal(arg, &block) == nil && block.BlockHash != nil {
+			return true
+		}
+	}
+	return false
+}
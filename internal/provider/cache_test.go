@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// countingCaller returns the number of calls it got so far as the result of every call, after waiting for release
+// when set.
+type countingCaller struct {
+	mutex   sync.Mutex
+	calls   int
+	err     error
+	release chan struct{}
+}
+
+func (c *countingCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if c.release != nil {
+		<-c.release
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls++
+	if c.err != nil {
+		return c.err
+	}
+	raw, _ := json.Marshal(hexutil.Uint64(c.calls))
+	*result.(*json.RawMessage) = raw
+	return nil
+}
+
+func (c *countingCaller) count() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.calls
+}
+
+func callCache(t *testing.T, cache *Cache, method string, args ...interface{}) uint64 {
+	var result hexutil.Uint64
+	if err := cache.CallContext(context.Background(), &result, method, args...); err != nil {
+		t.Fatalf("failed to call %s: %v", method, err)
+	}
+	return uint64(result)
+}
+
+func TestCacheSharesCallsInFlight(t *testing.T) {
+	next := &countingCaller{release: make(chan struct{})}
+	cache := NewCache(next, 0)
+
+	var wg sync.WaitGroup
+	results := make([]uint64, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = callCache(t, cache, "eth_blockNumber")
+		}(i)
+	}
+	// let every call join the first one
+	time.Sleep(50 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	if got := next.count(); got != 1 {
+		t.Fatalf("got %d calls, want 1", got)
+	}
+	for _, result := range results {
+		if result != 1 {
+			t.Fatalf("got results %v, want the result of the first call", results)
+		}
+	}
+
+	// without a ttl, completed calls are sent again
+	if got := callCache(t, cache, "eth_blockNumber"); got != 2 {
+		t.Fatalf("got %d, want 2", got)
+	}
+}
+
+func TestCacheKeepsResultsForTTL(t *testing.T) {
+	now := time.Unix(1000, 0)
+	next := &countingCaller{}
+	cache := NewCache(next, 5*time.Second)
+	cache.now = func() time.Time { return now }
+
+	if got := callCache(t, cache, "eth_getBalance", "0x01", "latest"); got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+	now = now.Add(4 * time.Second)
+	if got := callCache(t, cache, "eth_getBalance", "0x01", "latest"); got != 1 {
+		t.Fatalf("got %d within the ttl, want the cached 1", got)
+	}
+	if got := callCache(t, cache, "eth_getBalance", "0x02", "latest"); got != 2 {
+		t.Fatalf("got %d for other params, want 2", got)
+	}
+	now = now.Add(2 * time.Second)
+	if got := callCache(t, cache, "eth_getBalance", "0x01", "latest"); got != 3 {
+		t.Fatalf("got %d after the ttl, want 3", got)
+	}
+}
+
+func TestCacheKeepsResultsPinningBlockHash(t *testing.T) {
+	now := time.Unix(1000, 0)
+	next := &countingCaller{}
+	cache := NewCache(next, 5*time.Second)
+	cache.now = func() time.Time { return now }
+
+	hash := common.HexToHash("0x1234")
+	callCache(t, cache, "eth_getBlockTransactionCountByHash", hash)
+	callCache(t, cache, "eth_getBalance", "0x01", rpc.BlockNumberOrHashWithHash(hash, false))
+	now = now.Add(time.Hour)
+
+	if got := callCache(t, cache, "eth_getBlockTransactionCountByHash", hash); got != 1 {
+		t.Fatalf("got %d, want the cached 1", got)
+	}
+	if got := callCache(t, cache, "eth_getBalance", "0x01", rpc.BlockNumberOrHashWithHash(hash, false)); got != 2 {
+		t.Fatalf("got %d, want the cached 2", got)
+	}
+	if got := next.count(); got != 2 {
+		t.Fatalf("got %d calls, want 2", got)
+	}
+}
+
+func TestCacheDoesNotKeepErrors(t *testing.T) {
+	next := &countingCaller{err: errors.New("unavailable")}
+	cache := NewCache(next, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		var result hexutil.Uint64
+		if err := cache.CallContext(context.Background(), &result, "eth_blockNumber"); err == nil {
+			t.Fatalf("expected an error")
+		}
+	}
+	if got := next.count(); got != 2 {
+		t.Fatalf("got %d calls, want 2", got)
+	}
+}
@@ -0,0 +1,367 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// Defaults of the retry config.
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+)
+
+// rateLimitErrorCodes are the JSON-RPC error codes providers report rate limiting with, the limit exceeded code of
+// EIP-1474 and the HTTP status some providers reuse.
+var rateLimitErrorCodes = map[int]bool{
+	-32005: true,
+	429:    true,
+}
+
+// tokenBucket fills at rate tokens per second, up to burst tokens.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	if burst == 0 {
+		burst = rate
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, now: time.Now, tokens: burst, last: time.Now()}
+}
+
+// reserve takes cost tokens, and returns how long to wait until the bucket held them. Tokens taken ahead of time leave
+// the bucket in debt, so later reservations queue behind, and requests costing more than the burst wait for the
+// bucket to refill their whole cost.
+func (b *tokenBucket) reserve(cost float64) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := b.now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	b.tokens -= cost
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel gives back the tokens of an abandoned reservation.
+func (b *tokenBucket) cancel(cost float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+cost)
+}
+
+// limitTransport waits for the rate limit of a provider before sending requests, and retries the ones failing with
+// a retryable error with exponential backoff and jitter.
+type limitTransport struct {
+	base http.RoundTripper
+	// bucket is only set when rate limiting is enabled.
+	bucket  *tokenBucket
+	weights map[string]float64
+	retry   config.Retry
+	// metrics are only set when instrumented.
+	metrics *ProviderMetrics
+	random  func() float64
+}
+
+// newLimitTransport returns base unchanged when cfg enables neither rate limiting nor retries.
+func newLimitTransport(base http.RoundTripper, cfg config.ProviderConfig, metrics *ProviderMetrics) http.RoundTripper {
+	if cfg.RateLimit.RequestsPerSecond <= 0 && cfg.Retry.MaxRetries <= 0 {
+		return base
+	}
+	t := &limitTransport{
+		base:    base,
+		weights: cfg.RateLimit.MethodWeights,
+		retry:   cfg.Retry,
+		metrics: metrics,
+		random:  rand.Float64,
+	}
+	if cfg.RateLimit.RequestsPerSecond > 0 {
+		t.bucket = newTokenBucket(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+	}
+	if t.retry.InitialBackoff == 0 {
+		t.retry.InitialBackoff = defaultInitialBackoff
+	}
+	if t.retry.MaxBackoff == 0 {
+		t.retry.MaxBackoff = defaultMaxBackoff
+	}
+	return t
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	calls, batch := requestCalls(req)
+	method := requestMethod(calls, batch)
+	pending := newPendingRequest(req, calls, batch)
+
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req.Context(), t.cost(pending), method); err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(attemptReq)
+		// the responses of the last attempt are left as is, since splitting them would answer their calls twice
+		last := attempt >= t.retry.MaxRetries || pending == nil
+		var retryAfter time.Duration
+		retryable := false
+		if !last {
+			retryAfter, retryable = t.isRetryable(req, resp, err, pending)
+		}
+		if !retryable {
+			if resp != nil && pending != nil {
+				pending.complete(resp)
+			}
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), t.backoff(attempt, retryAfter)); err != nil {
+			return nil, err
+		}
+		attemptReq = pending.request(req)
+		if t.metrics != nil {
+			t.metrics.retries.WithLabelValues(method).Inc()
+		}
+	}
+}
+
+// cost returns the weight of the calls of a request, or 1 for requests that aren't JSON-RPC.
+func (t *limitTransport) cost(pending *pendingRequest) float64 {
+	if pending == nil || len(pending.calls) == 0 {
+		return 1
+	}
+	cost := float64(0)
+	for _, call := range pending.calls {
+		cost += t.weight(call.Method)
+	}
+	return cost
+}
+
+func (t *limitTransport) weight(method string) float64 {
+	if weight, ok := t.weights[method]; ok {
+		return weight
+	}
+	return 1
+}
+
+// wait waits for the rate limit to allow a request of the given cost.
+func (t *limitTransport) wait(ctx context.Context, cost float64, method string) error {
+	if t.bucket == nil {
+		return nil
+	}
+	delay := t.bucket.reserve(cost)
+	if delay == 0 {
+		return nil
+	}
+	if t.metrics != nil {
+		t.metrics.throttled.WithLabelValues(method).Inc()
+		t.metrics.throttledSeconds.WithLabelValues(method).Add(delay.Seconds())
+	}
+	if err := sleep(ctx, delay); err != nil {
+		t.bucket.cancel(cost)
+		return err
+	}
+	return nil
+}
+
+// isRetryable returns whether a request failed with a retryable error, along with the wait its Retry-After header
+// asks for, if any. The body of successful responses is read to find JSON-RPC rate limiting errors, and replaced.
+// The calls of a batch that succeeded are kept by pending, so only the rate limited ones are sent again.
+func (t *limitTransport) isRetryable(req *http.Request, resp *http.Response, err error, pending *pendingRequest) (time.Duration, bool) {
+	if err != nil {
+		// errors of cancelled requests are final
+		return 0, req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return retryAfter(resp), true
+	case http.StatusOK:
+	default:
+		return 0, false
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil || pending == nil {
+		return 0, false
+	}
+	return 0, pending.split(body)
+}
+
+// pendingRequest holds the JSON-RPC calls of a request still to be answered. Calls of a batch failing with a rate
+// limiting error are sent again on their own batch, and their responses merged with those of the calls that
+// succeeded before.
+type pendingRequest struct {
+	batch bool
+	calls []jsonrpcMessage
+	// raw holds the calls as sent, matching calls.
+	raw  []json.RawMessage
+	body []byte
+	// answered holds the responses of the calls of a batch no longer pending.
+	answered []json.RawMessage
+}
+
+// newPendingRequest returns nil for requests that can't be sent again.
+func newPendingRequest(req *http.Request, calls []jsonrpcMessage, batch bool) *pendingRequest {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	pending := &pendingRequest{batch: batch, calls: calls, body: content}
+	if batch && (json.Unmarshal(content, &pending.raw) != nil || len(pending.raw) != len(calls)) {
+		pending.batch = false
+	}
+	return pending
+}
+
+// split returns whether the response body holds rate limiting errors, in which case, for batches, only the calls
+// that got them remain pending.
+func (p *pendingRequest) split(body []byte) bool {
+	responses, _ := parseMessages(body)
+	limited := map[string]bool{}
+	for _, response := range responses {
+		var rpcErr struct {
+			Code int `json:"code"`
+		}
+		if len(response.Error) > 0 && json.Unmarshal(response.Error, &rpcErr) == nil && rateLimitErrorCodes[rpcErr.Code] {
+			limited[string(response.ID)] = true
+		}
+	}
+	if len(limited) == 0 || !p.batch {
+		return len(limited) > 0
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil || len(raw) != len(responses) {
+		// the calls can't be told apart, so the whole batch is sent again
+		return true
+	}
+	for i, response := range responses {
+		if !limited[string(response.ID)] {
+			p.answered = append(p.answered, raw[i])
+		}
+	}
+	var calls []jsonrpcMessage
+	var requests []json.RawMessage
+	for i, call := range p.calls {
+		if limited[string(call.ID)] {
+			calls = append(calls, call)
+			requests = append(requests, p.raw[i])
+		}
+	}
+	p.calls, p.raw = calls, requests
+	p.body, _ = json.Marshal(requests)
+	return true
+}
+
+// request returns a request sending the pending calls.
+func (p *pendingRequest) request(req *http.Request) *http.Request {
+	body := p.body
+	retry := req.Clone(req.Context())
+	retry.Body = ioutil.NopCloser(bytes.NewReader(body))
+	retry.ContentLength = int64(len(body))
+	retry.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return retry
+}
+
+// complete adds the responses of the calls answered by earlier attempts to the final response of a batch.
+func (p *pendingRequest) complete(resp *http.Response) {
+	if len(p.answered) == 0 || resp.StatusCode != http.StatusOK {
+		return
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	var responses []json.RawMessage
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return
+	}
+	merged, err := json.Marshal(append(p.answered, responses...))
+	if err != nil {
+		return
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(merged))
+	resp.ContentLength = int64(len(merged))
+	resp.Header.Del("Content-Length")
+}
+
+// backoff returns the wait before a retry, between half and all of the exponential backoff of the attempt, or the
+// wait a Retry-After header asks for, both up to the max backoff.
+func (t *limitTransport) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if retryAfter > t.retry.MaxBackoff {
+			return t.retry.MaxBackoff
+		}
+		return retryAfter
+	}
+	backoff := t.retry.MaxBackoff
+	if attempt < 32 && t.retry.InitialBackoff<<uint(attempt) < t.retry.MaxBackoff {
+		backoff = t.retry.InitialBackoff << uint(attempt)
+	}
+	return backoff/2 + time.Duration(t.random()*float64(backoff/2))
+}
+
+// retryAfter returns the wait the Retry-After header of resp asks for, in seconds or as a date.
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,258 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// newFlakyServer serves the mock JSON-RPC handler once failures requests were answered by fail.
+func newFlakyServer(t *testing.T, failures int, fail http.HandlerFunc) *httptest.Server {
+	var mutex sync.Mutex
+	handler := newMockJSONRPCHandler(t, http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		failing := failures > 0
+		failures--
+		mutex.Unlock()
+		if failing {
+			fail(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialLimited(t *testing.T, url string, cfg config.ProviderConfig) (*rpc.Client, *ProviderMetrics) {
+	metrics := NewMetrics(mockBlockchainName).Provider("eth")
+	client, err := Dial(url, cfg, metrics)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client, metrics
+}
+
+var testRetry = config.Retry{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+func TestRetryTooManyRequests(t *testing.T) {
+	server := newFlakyServer(t, 2, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	client, metrics := dialLimited(t, server.URL, config.ProviderConfig{Retry: testRetry})
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "0x10" {
+		t.Fatalf("got %q, want 0x10", result)
+	}
+	if got := testutil.ToFloat64(metrics.retries.WithLabelValues("eth_blockNumber")); got != 2 {
+		t.Fatalf("got %v retries, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.rateLimited.WithLabelValues("eth_blockNumber")); got != 2 {
+		t.Fatalf("got %v rate limited requests, want 2", got)
+	}
+}
+
+func TestRetryJSONRPCRateLimitError(t *testing.T) {
+	server := newFlakyServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "error": {"code": -32005, "message": "limit exceeded"}}`))
+	})
+	client, metrics := dialLimited(t, server.URL, config.ProviderConfig{Retry: testRetry})
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.retries.WithLabelValues("eth_blockNumber")); got != 1 {
+		t.Fatalf("got %v retries, want 1", got)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server := newFlakyServer(t, 5, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client, metrics := dialLimited(t, server.URL, config.ProviderConfig{Retry: testRetry})
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err == nil {
+		t.Fatalf("expected an HTTP error")
+	}
+	if got := testutil.ToFloat64(metrics.retries.WithLabelValues("eth_blockNumber")); got != 2 {
+		t.Fatalf("got %v retries, want 2", got)
+	}
+}
+
+func TestRetryIgnoresOtherErrors(t *testing.T) {
+	server := newFlakyServer(t, 5, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	client, metrics := dialLimited(t, server.URL, config.ProviderConfig{Retry: testRetry})
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err == nil {
+		t.Fatalf("expected an HTTP error")
+	}
+	if got := testutil.CollectAndCount(metrics.retries); got != 0 {
+		t.Fatalf("got %d retried methods, want none", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := newFlakyServer(t, 0, nil)
+	client, metrics := dialLimited(t, server.URL, config.ProviderConfig{RateLimit: config.RateLimit{
+		RequestsPerSecond: 20,
+		Burst:             1,
+	}})
+
+	start := time.Now()
+	var result string
+	for i := 0; i < 3; i++ {
+		if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("sent 3 requests in %v, want them 50ms apart", elapsed)
+	}
+	if got := testutil.ToFloat64(metrics.throttled.WithLabelValues("eth_blockNumber")); got != 2 {
+		t.Fatalf("got %v throttled requests, want 2", got)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	bucket := newTokenBucket(10, 2)
+	bucket.now = func() time.Time { return now }
+	bucket.last = now
+
+	for _, test := range []struct {
+		cost  float64
+		delay time.Duration
+	}{
+		{1, 0},
+		{1, 0},
+		{1, 100 * time.Millisecond},
+		// costs above the burst wait for their whole cost
+		{5, 600 * time.Millisecond},
+	} {
+		if delay := bucket.reserve(test.cost); delay != test.delay {
+			t.Fatalf("got a delay of %v for a cost of %v, want %v", delay, test.cost, test.delay)
+		}
+	}
+
+	now = now.Add(time.Second)
+	if delay := bucket.reserve(2); delay != 0 {
+		t.Fatalf("got a delay of %v once refilled, want none", delay)
+	}
+}
+
+func TestRetryRateLimitedBatchCalls(t *testing.T) {
+	var mutex sync.Mutex
+	var sizes []int
+	handler := newMockJSONRPCHandler(t, http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("could not read the request: %v", err)
+		}
+		calls, _ := parseMessages(body)
+		mutex.Lock()
+		sizes = append(sizes, len(calls))
+		first := len(sizes) == 1
+		mutex.Unlock()
+		if first {
+			// the second call of the first batch is rate limited
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `[{"jsonrpc": "2.0", "id": %s, "result": "0x10"}, {"jsonrpc": "2.0", "id": %s, "error": {"code": -32005, "message": "limit exceeded"}}]`,
+				calls[0].ID, calls[1].ID)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client, metrics := dialLimited(t, server.URL, config.ProviderConfig{Retry: testRetry})
+
+	batch := []rpc.BatchElem{
+		{Method: "eth_blockNumber", Result: new(string)},
+		{Method: "eth_blockNumber", Result: new(string)},
+	}
+	if err := client.BatchCallContext(context.Background(), batch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, elem := range batch {
+		if elem.Error != nil || *elem.Result.(*string) != "0x10" {
+			t.Fatalf("got %v, %v for call %d, want 0x10", *elem.Result.(*string), elem.Error, i)
+		}
+	}
+	if len(sizes) != 2 || sizes[1] != 1 {
+		t.Fatalf("got requests of %v calls, want the rate limited call sent again alone", sizes)
+	}
+	if got := testutil.ToFloat64(metrics.retries.WithLabelValues(batchMethod)); got != 1 {
+		t.Fatalf("got %v retries, want 1", got)
+	}
+}
+
+func TestPartlyRateLimitedBatchWithoutRetries(t *testing.T) {
+	for _, retry := range []config.Retry{{}, {MaxRetries: 1, InitialBackoff: time.Millisecond}} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("could not read the request: %v", err)
+			}
+			// the last call of every batch is rate limited
+			calls, _ := parseMessages(body)
+			var responses []string
+			for i, call := range calls {
+				if i == len(calls)-1 {
+					responses = append(responses, fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "error": {"code": -32005, "message": "limit exceeded"}}`, call.ID))
+				} else {
+					responses = append(responses, fmt.Sprintf(`{"jsonrpc": "2.0", "id": %s, "result": "0x10"}`, call.ID))
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+		}))
+		client, _ := dialLimited(t, server.URL, config.ProviderConfig{
+			RateLimit: config.RateLimit{RequestsPerSecond: 1000},
+			Retry:     retry,
+		})
+
+		batch := []rpc.BatchElem{
+			{Method: "eth_blockNumber", Result: new(string)},
+			{Method: "eth_blockNumber", Result: new(string)},
+			{Method: "eth_blockNumber", Result: new(string)},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := client.BatchCallContext(ctx, batch); err != nil {
+			t.Fatalf("unexpected error with %d retries: %v", retry.MaxRetries, err)
+		}
+		cancel()
+		server.Close()
+
+		// every call is answered once, the rate limited one with its error
+		for i, elem := range batch {
+			if limited := elem.Error != nil; limited != (i == len(batch)-1) {
+				t.Fatalf("got error %v for call %d with %d retries", elem.Error, i, retry.MaxRetries)
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/collectors/constants"
+)
+
+// Outcomes of the JSON-RPC calls.
+const (
+	outcomeSuccess      = "success"
+	outcomeRPCError     = "rpc_error"
+	outcomeHTTPError    = "http_error"
+	outcomeNetworkError = "network_error"
+)
+
+// batchMethod labels the request metrics of batches, which hold several calls, and unknownMethod those of requests
+// that aren't JSON-RPC.
+const (
+	batchMethod   = "batch"
+	unknownMethod = "unknown"
+)
+
+// Metrics instruments the JSON-RPC calls sent to the providers.
+type Metrics struct {
+	calls        *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	rateLimited  *prometheus.CounterVec
+	// throttled and throttledSeconds record the waits on client-side rate limits, and retries the retried requests.
+	throttled        *prometheus.CounterVec
+	throttledSeconds *prometheus.CounterVec
+	retries          *prometheus.CounterVec
+}
+
+func NewMetrics(blockchain string) *Metrics {
+	constLabels := prometheus.Labels{constants.BlockchainNameLabel: blockchain}
+	return &Metrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ethexporter_rpc_calls_total",
+			Help:        "JSON-RPC calls sent to the provider, by method and outcome (success, rpc_error, http_error or network_error)",
+			ConstLabels: constLabels,
+		}, []string{"provider", "method", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "ethexporter_rpc_request_duration_seconds",
+			Help:        "Duration of the HTTP requests sent to the provider, by method or batch",
+			ConstLabels: constLabels,
+			Buckets:     []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"provider", "method"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "ethexporter_rpc_response_size_bytes",
+			Help:        "Size of the HTTP responses of the provider, by method or batch",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"provider", "method"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ethexporter_rpc_rate_limited_total",
+			Help:        "HTTP requests the provider answered with 429 Too Many Requests, by method or batch",
+			ConstLabels: constLabels,
+		}, []string{"provider", "method"}),
+		throttled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ethexporter_rpc_throttled_total",
+			Help:        "HTTP requests delayed by the client-side rate limit of the provider, by method or batch",
+			ConstLabels: constLabels,
+		}, []string{"provider", "method"}),
+		throttledSeconds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ethexporter_rpc_throttled_seconds_total",
+			Help:        "Time HTTP requests waited on the client-side rate limit of the provider, by method or batch",
+			ConstLabels: constLabels,
+		}, []string{"provider", "method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ethexporter_rpc_retries_total",
+			Help:        "HTTP requests retried after a retryable error, by method or batch",
+			ConstLabels: constLabels,
+		}, []string{"provider", "method"}),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.calls.Describe(ch)
+	m.duration.Describe(ch)
+	m.responseSize.Describe(ch)
+	m.rateLimited.Describe(ch)
+	m.throttled.Describe(ch)
+	m.throttledSeconds.Describe(ch)
+	m.retries.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.calls.Collect(ch)
+	m.duration.Collect(ch)
+	m.responseSize.Collect(ch)
+	m.rateLimited.Collect(ch)
+	m.throttled.Collect(ch)
+	m.throttledSeconds.Collect(ch)
+	m.retries.Collect(ch)
+}
+
+// Provider returns the metrics of the calls sent to the named provider. It is safe to call on a nil Metrics, and
+// returns nil, recording nothing.
+func (m *Metrics) Provider(name string) *ProviderMetrics {
+	if m == nil {
+		return nil
+	}
+	labels := prometheus.Labels{"provider": name}
+	return &ProviderMetrics{
+		calls:        m.calls.MustCurryWith(labels),
+		duration:     m.duration.MustCurryWith(labels).(*prometheus.HistogramVec),
+		responseSize: m.responseSize.MustCurryWith(labels).(*prometheus.HistogramVec),
+		rateLimited:  m.rateLimited.MustCurryWith(labels),
+
+		throttled:        m.throttled.MustCurryWith(labels),
+		throttledSeconds: m.throttledSeconds.MustCurryWith(labels),
+		retries:          m.retries.MustCurryWith(labels),
+	}
+}
+
+// ProviderMetrics are the metrics of a single provider.
+type ProviderMetrics struct {
+	calls        *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	rateLimited  *prometheus.CounterVec
+	// throttled and throttledSeconds record the waits on client-side rate limits, and retries the retried requests.
+	throttled        *prometheus.CounterVec
+	throttledSeconds *prometheus.CounterVec
+	retries          *prometheus.CounterVec
+}
+
+// jsonrpcMessage holds the fields of JSON-RPC requests and responses needed to attribute outcomes to methods.
+type jsonrpcMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// parseMessages decodes a single JSON-RPC message or a batch of them.
+func parseMessages(body []byte) ([]jsonrpcMessage, bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var messages []jsonrpcMessage
+		if err := json.Unmarshal(body, &messages); err != nil {
+			return nil, true
+		}
+		return messages, true
+	}
+	var message jsonrpcMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, false
+	}
+	return []jsonrpcMessage{message}, false
+}
+
+// instrumentedTransport records the metrics of the JSON-RPC calls sent through it.
+type instrumentedTransport struct {
+	base    http.RoundTripper
+	metrics *ProviderMetrics
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	calls, batch := requestCalls(req)
+	method := requestMethod(calls, batch)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.observeCalls(calls, nil, outcomeNetworkError)
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	t.metrics.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		t.observeCalls(calls, nil, outcomeNetworkError)
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	t.metrics.responseSize.WithLabelValues(method).Observe(float64(len(body)))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.metrics.rateLimited.WithLabelValues(method).Inc()
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		t.observeCalls(calls, nil, outcomeHTTPError)
+		return resp, nil
+	}
+	responses, _ := parseMessages(body)
+	t.observeCalls(calls, responses, "")
+	return resp, nil
+}
+
+// requestMethod labels the metrics of a request with its method, or as a batch.
+func requestMethod(calls []jsonrpcMessage, batch bool) string {
+	switch {
+	case batch:
+		return batchMethod
+	case len(calls) == 1:
+		return calls[0].Method
+	}
+	return unknownMethod
+}
+
+// requestCalls returns the calls of a request, and whether they are a batch.
+func requestCalls(req *http.Request) ([]jsonrpcMessage, bool) {
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close()
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, false
+	}
+	return parseMessages(content)
+}
+
+// observeCalls counts each call with outcome or, when outcome is empty, with the outcome of its response.
+func (t *instrumentedTransport) observeCalls(calls, responses []jsonrpcMessage, outcome string) {
+	byID := map[string]jsonrpcMessage{}
+	for _, response := range responses {
+		byID[string(response.ID)] = response
+	}
+	for _, call := range calls {
+		callOutcome := outcome
+		if callOutcome == "" {
+			response, ok := byID[string(call.ID)]
+			switch {
+			case !ok, len(response.Error) > 0 && string(response.Error) != "null":
+				callOutcome = outcomeRPCError
+			default:
+				callOutcome = outcomeSuccess
+			}
+		}
+		t.metrics.calls.WithLabelValues(call.Method, callOutcome).Inc()
+	}
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+const mockBlockchainName = "test_blockchain"
+
+// newMockJSONRPCServer answers eth_blockNumber, fails any other method with a JSON-RPC error, and answers every
+// request with the given status when it isn't 200.
+func newMockJSONRPCServer(t *testing.T, status int) *httptest.Server {
+	return httptest.NewServer(newMockJSONRPCHandler(t, status))
+}
+
+func newMockJSONRPCHandler(t *testing.T, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("could not decode the request: %v", err)
+		}
+		calls, batch := parseMessages(body)
+
+		var responses []map[string]interface{}
+		for _, call := range calls {
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": call.ID}
+			if call.Method == "eth_blockNumber" {
+				response["result"] = "0x10"
+			} else {
+				response["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+			}
+			responses = append(responses, response)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var err error
+		if batch {
+			err = json.NewEncoder(w).Encode(responses)
+		} else {
+			err = json.NewEncoder(w).Encode(responses[0])
+		}
+		if err != nil {
+			t.Fatalf("could not write a response: %v", err)
+		}
+	})
+}
+
+func dialInstrumented(t *testing.T, url string) (*rpc.Client, *ProviderMetrics) {
+	metrics := NewMetrics(mockBlockchainName).Provider("eth")
+	client, err := Dial(url, config.ProviderConfig{}, metrics)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client, metrics
+}
+
+func TestInstrumentedCalls(t *testing.T) {
+	server := newMockJSONRPCServer(t, http.StatusOK)
+	defer server.Close()
+	client, metrics := dialInstrumented(t, server.URL)
+	defer client.Close()
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.CallContext(context.Background(), &result, "eth_hashrate"); err == nil {
+		t.Fatalf("expected a JSON-RPC error")
+	}
+	batch := []rpc.BatchElem{
+		{Method: "eth_blockNumber", Result: new(string)},
+		{Method: "eth_blockNumber", Result: new(string)},
+		{Method: "eth_mining", Result: new(bool)},
+	}
+	if err := client.BatchCallContext(context.Background(), batch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, test := range []struct {
+		method, outcome string
+		count           float64
+	}{
+		{"eth_blockNumber", outcomeSuccess, 3},
+		{"eth_hashrate", outcomeRPCError, 1},
+		{"eth_mining", outcomeRPCError, 1},
+	} {
+		if got := testutil.ToFloat64(metrics.calls.WithLabelValues(test.method, test.outcome)); got != test.count {
+			t.Fatalf("got %v %s calls with outcome %s, want %v", got, test.method, test.outcome, test.count)
+		}
+	}
+	if got := testutil.CollectAndCount(metrics.duration); got != 3 {
+		t.Fatalf("got %d duration histograms, want one for eth_blockNumber, eth_hashrate and batch", got)
+	}
+	if got := testutil.CollectAndCount(metrics.responseSize); got != 3 {
+		t.Fatalf("got %d response size histograms, want 3", got)
+	}
+}
+
+func TestInstrumentedRateLimited(t *testing.T) {
+	server := newMockJSONRPCServer(t, http.StatusTooManyRequests)
+	defer server.Close()
+	client, metrics := dialInstrumented(t, server.URL)
+	defer client.Close()
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err == nil {
+		t.Fatalf("expected an HTTP error")
+	}
+	if got := testutil.ToFloat64(metrics.rateLimited.WithLabelValues("eth_blockNumber")); got != 1 {
+		t.Fatalf("got %v rate limited requests, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.calls.WithLabelValues("eth_blockNumber", outcomeHTTPError)); got != 1 {
+		t.Fatalf("got %v calls with outcome http_error, want 1", got)
+	}
+}
+
+func TestInstrumentedNetworkError(t *testing.T) {
+	server := newMockJSONRPCServer(t, http.StatusOK)
+	client, metrics := dialInstrumented(t, server.URL)
+	defer client.Close()
+	server.Close()
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err == nil {
+		t.Fatalf("expected a network error")
+	}
+	if got := testutil.ToFloat64(metrics.calls.WithLabelValues("eth_blockNumber", outcomeNetworkError)); got != 1 {
+		t.Fatalf("got %v calls with outcome network_error, want 1", got)
+	}
+}
+
+func TestMetricsProviderNil(t *testing.T) {
+	var metrics *Metrics
+	if metrics.Provider("eth") != nil {
+		t.Fatalf("expected nil provider metrics")
+	}
+}
@@ -0,0 +1,46 @@
+// Package provider builds the clients reaching the providers, applying their provider config.
+package provider
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// NewHTTPClient returns a client sending the requests of a provider with the headers, authentication, transport, rate
+// limit and retry settings of cfg. The JSON-RPC calls sent through it are recorded in metrics, unless nil. Every
+// provider is meant to have a single client, so its rate limit is shared by all the collectors querying it.
+func NewHTTPClient(cfg config.ProviderConfig, metrics *ProviderMetrics) (*http.Client, error) {
+	base, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper
+	transport, err = newAuthTransport(base, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if metrics != nil {
+		transport = &instrumentedTransport{base: transport, metrics: metrics}
+	}
+	// every attempt is instrumented, without the waits on the rate limit
+	transport = newLimitTransport(transport, cfg, metrics)
+	return &http.Client{Transport: transport, Timeout: cfg.Timeout}, nil
+}
+
+// Dial connects to the JSON-RPC endpoint at rawurl. HTTP endpoints are reached with NewHTTPClient, while websocket
+// and IPC endpoints, which don't support the provider config nor metrics, are dialed as is.
+func Dial(rawurl string, cfg config.ProviderConfig, metrics *ProviderMetrics) (*rpc.Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return rpc.Dial(rawurl)
+	}
+	client, err := NewHTTPClient(cfg, metrics)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to configure provider %s", u.Host)
+	}
+	return rpc.DialHTTPWithClient(rawurl, client)
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/thepalbi/ethereum-prometheus-exporter/internal/config"
+)
+
+// newClientCertificate returns a self-signed client certificate and key, PEM-encoded.
+func newClientCertificate(t *testing.T) (*x509.Certificate, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate a key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "exporter"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("could not create a certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("could not parse the certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("could not marshal the key: %v", err)
+	}
+	return cert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestDialMutualTLS(t *testing.T) {
+	clientCert, certPEM, keyPEM := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	var headers http.Header
+	server := httptest.NewUnstartedServer(newMockRPCHandler(t, &headers))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	cfg := config.ProviderConfig{
+		TLSConfig: config.TLSConfig{
+			CAFile:   writeSecret(t, string(serverCA)),
+			CertFile: writeSecret(t, string(certPEM)),
+			KeyFile:  writeSecret(t, string(keyPEM)),
+		},
+	}
+	client, err := Dial(server.URL, cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// without a client certificate the handshake fails
+	cfg.TLSConfig.CertFile, cfg.TLSConfig.KeyFile = "", ""
+	client, err = Dial(server.URL, cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err == nil {
+		t.Fatalf("expected a TLS handshake error")
+	}
+}
+
+func TestDialProxy(t *testing.T) {
+	var headers http.Header
+	proxy := newMockRPCServer(t, &headers)
+	defer proxy.Close()
+
+	client, err := Dial("http://node.invalid:8545", config.ProviderConfig{ProxyURL: proxy.URL}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	var result string
+	if err := client.CallContext(context.Background(), &result, "eth_blockNumber"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "0x10" {
+		t.Fatalf("got %q, want 0x10 from the proxy", result)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := newTransport(config.ProviderConfig{
+		MaxIdleConns:    20,
+		MaxConnsPerHost: 10,
+		IdleConnTimeout: time.Minute,
+		TLSConfig:       config.TLSConfig{ServerName: "node.example.org", InsecureSkipVerify: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if transport.MaxIdleConns != 20 || transport.MaxIdleConnsPerHost != 20 {
+		t.Fatalf("got %d idle connections and %d per host, want 20", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxConnsPerHost != 10 {
+		t.Fatalf("got %d connections per host, want 10", transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != time.Minute {
+		t.Fatalf("got idle connection timeout %v, want 1m", transport.IdleConnTimeout)
+	}
+	if transport.TLSClientConfig.ServerName != "node.example.org" || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("unexpected TLS config %#v", transport.TLSClientConfig)
+	}
+}
+
+func TestNewTransportInvalidCAFile(t *testing.T) {
+	_, err := newTransport(config.ProviderConfig{TLSConfig: config.TLSConfig{CAFile: writeSecret(t, "not a certificate")}})
+	if err == nil {
+		t.Fatalf("expected an invalid CA error")
+	}
+}
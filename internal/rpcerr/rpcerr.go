@@ -0,0 +1,32 @@
+// Package rpcerr tells apart the JSON-RPC errors returned by nodes.
+package rpcerr
+
+import (
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// JSON-RPC error codes returned by nodes.
+const (
+	// ExecutionRevertedCode is returned for reverted calls, which older nodes report with ServerErrorCode instead.
+	ExecutionRevertedCode = 3
+	ServerErrorCode       = -32000
+	MethodNotFoundCode    = -32601
+	InvalidParamsCode     = -32602
+)
+
+// Code returns the JSON-RPC error code of err, and false when it isn't a JSON-RPC error.
+func Code(err error) (int, bool) {
+	rpcErr, ok := errors.Cause(err).(rpc.Error)
+	if !ok {
+		return 0, false
+	}
+	return rpcErr.ErrorCode(), true
+}
+
+// IsMethodNotFound returns whether err means the called method isn't served by the node, such as a namespace it
+// doesn't expose.
+func IsMethodNotFound(err error) bool {
+	code, ok := Code(err)
+	return ok && code == MethodNotFoundCode
+}
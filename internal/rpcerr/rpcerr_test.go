@@ -0,0 +1,30 @@
+package rpcerr
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockError struct {
+	code int
+}
+
+func (e mockError) Error() string  { return "mock error" }
+func (e mockError) ErrorCode() int { return e.code }
+
+func TestCode(t *testing.T) {
+	code, ok := Code(errors.Wrap(mockError{MethodNotFoundCode}, "failed"))
+	assert.True(t, ok)
+	assert.Equal(t, MethodNotFoundCode, code)
+
+	_, ok = Code(errors.New("connection refused"))
+	assert.False(t, ok)
+}
+
+func TestIsMethodNotFound(t *testing.T) {
+	assert.True(t, IsMethodNotFound(mockError{MethodNotFoundCode}))
+	assert.False(t, IsMethodNotFound(mockError{InvalidParamsCode}))
+	assert.False(t, IsMethodNotFound(errors.New("connection refused")))
+}
@@ -0,0 +1,184 @@
+// Package scrape gathers collectors with the context of each scrape, bounded by the scrape timeout of Prometheus, so
+// a hung provider cuts the scrape short instead of blocking it.
+package scrape
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// TimeoutHeader is sent by Prometheus with the scrape timeout, in seconds.
+const TimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// timeoutOffset is left out of the scrape timeout of Prometheus to write the response.
+const timeoutOffset = 500 * time.Millisecond
+
+// Collector is a collector whose scrapes are bound to a context, such as the one of the scrape request.
+type Collector interface {
+	prometheus.Collector
+	CollectContext(ctx context.Context, ch chan<- prometheus.Metric)
+}
+
+// Collect scrapes collector with ctx when it is a Collector, and ignores ctx otherwise.
+func Collect(ctx context.Context, collector prometheus.Collector, ch chan<- prometheus.Metric) {
+	if c, ok := collector.(Collector); ok {
+		c.CollectContext(ctx, ch)
+		return
+	}
+	collector.Collect(ch)
+}
+
+// Timeout returns the scrape timeout sent by Prometheus in the request headers, less the time left to write the
+// response, and false when it isn't sent.
+func Timeout(header http.Header) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(header.Get(TimeoutHeader), 64)
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > 2*timeoutOffset {
+		timeout -= timeoutOffset
+	}
+	return timeout, true
+}
+
+// boundCollector scrapes a Collector with the context of a scrape.
+type boundCollector struct {
+	Collector
+	ctx context.Context
+}
+
+func (c boundCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(c.ctx, ch)
+}
+
+// onceKey is the context key of the values shared by the collectors of a scrape.
+type onceKey struct{}
+
+// onceValues holds the values computed once per scrape, by key.
+type onceValues struct {
+	mutex  sync.Mutex
+	values map[interface{}]*onceValue
+}
+
+type onceValue struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// WithScrape returns a copy of ctx scoping a scrape, whose collectors share the values computed with Once.
+func WithScrape(ctx context.Context) context.Context {
+	return context.WithValue(ctx, onceKey{}, &onceValues{values: map[interface{}]*onceValue{}})
+}
+
+// Scoped returns whether ctx is the context of a scrape.
+func Scoped(ctx context.Context) bool {
+	_, ok := ctx.Value(onceKey{}).(*onceValues)
+	return ok
+}
+
+// Once returns the value fn computes for key, computing it on the first call with the context of a scrape only, so
+// every collector of the scrape sees the same value. Outside of a scrape, fn is called every time.
+func Once(ctx context.Context, key interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	values, ok := ctx.Value(onceKey{}).(*onceValues)
+	if !ok {
+		return fn()
+	}
+	values.mutex.Lock()
+	value, ok := values.values[key]
+	if !ok {
+		value = &onceValue{}
+		values.values[key] = value
+	}
+	values.mutex.Unlock()
+
+	value.once.Do(func() {
+		value.value, value.err = fn()
+	})
+	return value.value, value.err
+}
+
+// Registry holds collectors and gathers them with the context of each scrape, through a registry created for the
+// scrape. Collectors are checked by a pedantic registry when registered.
+type Registry struct {
+	mutex      sync.RWMutex
+	checked    *prometheus.Registry
+	collectors []prometheus.Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checked: prometheus.NewPedanticRegistry()}
+}
+
+// Register registers collector, failing like prometheus.Registry.Register.
+func (r *Registry) Register(collector prometheus.Collector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err := r.checked.Register(collector); err != nil {
+		return err
+	}
+	r.collectors = append(r.collectors, collector)
+	return nil
+}
+
+// MustRegister registers collectors, panicking on the first one failing to be registered.
+func (r *Registry) MustRegister(collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		if err := r.Register(collector); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister unregisters collector, and returns whether it was registered.
+func (r *Registry) Unregister(collector prometheus.Collector) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.checked.Unregister(collector) {
+		return false
+	}
+	for i, c := range r.collectors {
+		if c == collector {
+			r.collectors = append(r.collectors[:i:i], r.collectors[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Gatherer returns a gatherer scraping the registered collectors with ctx, scoped to the scrape by WithScrape.
+func (r *Registry) Gatherer(ctx context.Context) prometheus.Gatherer {
+	ctx = WithScrape(ctx)
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	registry := prometheus.NewPedanticRegistry()
+	for _, collector := range r.collectors {
+		if c, ok := collector.(Collector); ok {
+			collector = boundCollector{c, ctx}
+		}
+		// already checked on registration
+		registry.MustRegister(collector)
+	}
+	return registry
+}
+
+// Handler serves the metrics of the registered collectors, scraped with the context of the request bounded by the
+// scrape timeout of Prometheus.
+func (r *Registry) Handler(opts promhttp.HandlerOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if timeout, ok := Timeout(req.Header); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		promhttp.HandlerFor(r.Gatherer(ctx), opts).ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,140 @@
+package scrape
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// deadlineCollector exports the time left before the deadline of its scrape context, or -1 without one.
+type deadlineCollector struct {
+	desc *prometheus.Desc
+}
+
+func newDeadlineCollector(name string) *deadlineCollector {
+	return &deadlineCollector{prometheus.NewDesc(name, "Time left to scrape", nil, nil)}
+}
+
+func (c *deadlineCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *deadlineCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *deadlineCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	left := -1.0
+	if deadline, ok := ctx.Deadline(); ok {
+		left = time.Until(deadline).Seconds()
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, left)
+}
+
+func TestTimeout(t *testing.T) {
+	for _, test := range []struct {
+		header  string
+		timeout time.Duration
+		ok      bool
+	}{
+		{"10", 9500 * time.Millisecond, true},
+		{"0.5", 500 * time.Millisecond, true},
+		{"", 0, false},
+		{"0", 0, false},
+		{"ten", 0, false},
+	} {
+		header := http.Header{}
+		if test.header != "" {
+			header.Set(TimeoutHeader, test.header)
+		}
+		timeout, ok := Timeout(header)
+		if timeout != test.timeout || ok != test.ok {
+			t.Fatalf("got %v, %v for %q, want %v, %v", timeout, ok, test.header, test.timeout, test.ok)
+		}
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister(newDeadlineCollector("scrape_time_left_seconds"))
+	server := httptest.NewServer(registry.Handler(promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	scrape := func(timeout string) string {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if timeout != "" {
+			req.Header.Set(TimeoutHeader, timeout)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return string(body)
+	}
+
+	if body := scrape(""); !strings.Contains(body, "scrape_time_left_seconds -1") {
+		t.Fatalf("expected no deadline without a scrape timeout, got:\n%s", body)
+	}
+	if body := scrape("10"); !strings.Contains(body, "scrape_time_left_seconds 9.") {
+		t.Fatalf("expected the scrape timeout less the offset, got:\n%s", body)
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	registry := NewRegistry()
+	collector := newDeadlineCollector("scrape_time_left_seconds")
+	registry.MustRegister(collector)
+	if err := registry.Register(newDeadlineCollector("scrape_time_left_seconds")); err == nil {
+		t.Fatalf("expected duplicate metrics to be rejected")
+	}
+
+	if !registry.Unregister(collector) {
+		t.Fatalf("expected the collector to be unregistered")
+	}
+	if registry.Unregister(collector) {
+		t.Fatalf("expected the collector to be unregistered only once")
+	}
+	families, err := registry.Gatherer(context.Background()).Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(families) != 0 {
+		t.Fatalf("got %d metric families, want none", len(families))
+	}
+}
+
+func TestOnce(t *testing.T) {
+	calls := 0
+	compute := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	ctx := WithScrape(context.Background())
+	for i := 0; i < 2; i++ {
+		if value, _ := Once(ctx, "head", compute); value != 1 {
+			t.Fatalf("got %v within a scrape, want the first value", value)
+		}
+	}
+	if value, _ := Once(WithScrape(context.Background()), "head", compute); value != 2 {
+		t.Fatalf("got %v for another scrape, want 2", value)
+	}
+	if value, _ := Once(context.Background(), "head", compute); value != 3 {
+		t.Fatalf("got %v outside of a scrape, want 3", value)
+	}
+}
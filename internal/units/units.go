@@ -0,0 +1,51 @@
+package units
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Unit is an ether denomination in which values are exported.
+type Unit string
+
+const (
+	Wei   Unit = "wei"
+	Gwei  Unit = "gwei"
+	Ether Unit = "ether"
+)
+
+var decimals = map[Unit]int64{
+	Wei:   0,
+	Gwei:  9,
+	Ether: 18,
+}
+
+// Parse returns the unit named by s, or def if s is empty.
+func Parse(s string, def Unit) (Unit, error) {
+	if s == "" {
+		return def, nil
+	}
+	u := Unit(s)
+	if _, ok := decimals[u]; !ok {
+		return "", errors.Errorf("unknown unit %q, expected one of wei, gwei or ether", s)
+	}
+	return u, nil
+}
+
+// FromWei converts an amount of wei into u, keeping as much precision as a float64 allows.
+func (u Unit) FromWei(wei *big.Int) float64 {
+	value := new(big.Float).SetInt(wei)
+	if d := decimals[u]; d > 0 {
+		divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(d), nil))
+		value.Quo(value, divisor)
+	}
+	f, _ := value.Float64()
+	return f
+}
+
+// FromEther converts an amount of ether into u.
+func (u Unit) FromEther(ether float64) float64 {
+	wei, _ := new(big.Float).Mul(big.NewFloat(ether), big.NewFloat(1e18)).Int(nil)
+	return u.FromWei(wei)
+}
@@ -0,0 +1,35 @@
+package units
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	u, err := Parse("", Ether)
+	assert.Nil(t, err)
+	assert.Equal(t, Ether, u)
+
+	u, err = Parse("gwei", Ether)
+	assert.Nil(t, err)
+	assert.Equal(t, Gwei, u)
+
+	_, err = Parse("finney", Ether)
+	assert.NotNil(t, err)
+}
+
+func TestFromWei(t *testing.T) {
+	wei, _ := new(big.Int).SetString("1708783933564349320", 10)
+
+	assert.Equal(t, 1708783933564349320.0, Wei.FromWei(wei))
+	assert.Equal(t, 1708783933.56434932, Gwei.FromWei(wei))
+	assert.Equal(t, 1.70878393356434932, Ether.FromWei(wei))
+}
+
+func TestFromEther(t *testing.T) {
+	assert.Equal(t, 5e17, Wei.FromEther(0.5))
+	assert.Equal(t, 5e8, Gwei.FromEther(0.5))
+	assert.Equal(t, 0.5, Ether.FromEther(0.5))
+}
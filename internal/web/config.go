@@ -0,0 +1,228 @@
+// Package web serves the exporter endpoints with the TLS and basic auth settings of a web config file, in the format
+// of the Prometheus exporter-toolkit.
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of a web config file.
+type Config struct {
+	TLSServerConfig *TLSServerConfig `yaml:"tls_server_config"`
+	// BasicAuthUsers maps usernames to their bcrypt-hashed password.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+}
+
+// TLSServerConfig configures the TLS listener. Relative paths are relative to the web config file.
+type TLSServerConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientAuthType is one of the tls.ClientAuthType names, and defaults to RequireAndVerifyClientCert when
+	// ClientCAFile is set, and NoClientCert otherwise.
+	ClientAuthType string `yaml:"client_auth_type"`
+	ClientCAFile   string `yaml:"client_ca_file"`
+	// MinVersion is one of TLS10, TLS11, TLS12 or TLS13, and defaults to TLS12.
+	MinVersion string `yaml:"min_version"`
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// LoadConfig reads and checks the web config file at path.
+func LoadConfig(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read web config")
+	}
+	config := new(Config)
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	// an empty file is an empty config
+	if err := decoder.Decode(config); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to parse web config %s", path)
+	}
+
+	for user, hash := range config.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, errors.Errorf("invalid bcrypt hash for basic_auth_users.%s in %s", user, path)
+		}
+	}
+	if tlsConfig := config.TLSServerConfig; tlsConfig != nil {
+		if tlsConfig.CertFile == "" || tlsConfig.KeyFile == "" {
+			return nil, errors.Errorf("tls_server_config.cert_file and key_file are required in %s", path)
+		}
+		dir := filepath.Dir(path)
+		tlsConfig.CertFile = relativeTo(dir, tlsConfig.CertFile)
+		tlsConfig.KeyFile = relativeTo(dir, tlsConfig.KeyFile)
+		tlsConfig.ClientCAFile = relativeTo(dir, tlsConfig.ClientCAFile)
+		if _, err := tlsConfig.clientAuth(); err != nil {
+			return nil, errors.Wrapf(err, "invalid tls_server_config in %s", path)
+		}
+		if _, ok := tlsVersions[tlsConfig.MinVersion]; !ok && tlsConfig.MinVersion != "" {
+			return nil, errors.Errorf("invalid tls_server_config.min_version %q in %s", tlsConfig.MinVersion, path)
+		}
+	}
+	return config, nil
+}
+
+func relativeTo(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func (c *TLSServerConfig) clientAuth() (tls.ClientAuthType, error) {
+	if c.ClientAuthType == "" {
+		if c.ClientCAFile != "" {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	}
+	clientAuth, ok := clientAuthTypes[c.ClientAuthType]
+	if !ok {
+		return 0, errors.Errorf("unknown client_auth_type %q", c.ClientAuthType)
+	}
+	if (clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert) && c.ClientCAFile == "" {
+		return 0, errors.Errorf("client_ca_file is required to verify client certificates")
+	}
+	return clientAuth, nil
+}
+
+// tlsConfig loads the certificates of c.
+func (c *TLSServerConfig) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the server certificate")
+	}
+	clientAuth, err := c.clientAuth()
+	if err != nil {
+		return nil, err
+	}
+	minVersion := uint16(tls.VersionTLS12)
+	if c.MinVersion != "" {
+		minVersion = tlsVersions[c.MinVersion]
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   clientAuth,
+		MinVersion:   minVersion,
+	}
+	if c.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read client_ca_file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no PEM-encoded certificates found in %s", c.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+	return config, nil
+}
+
+// fileStamp identifies a version of a file, by its modification time and size.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stat(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{info.ModTime(), info.Size()}, nil
+}
+
+// ConfigLoader caches a web config file along with its TLS config, reading them again once the web config file, or
+// one of the certificate and key files it refers to, changes its modification time or size.
+type ConfigLoader struct {
+	path string
+
+	mutex     sync.Mutex
+	stamps    map[string]fileStamp
+	config    *Config
+	tlsConfig *tls.Config
+}
+
+func NewConfigLoader(path string) *ConfigLoader {
+	return &ConfigLoader{path: path}
+}
+
+// Load returns the web config, and its TLS config when it has tls_server_config. Failures aren't cached, so a broken
+// file is read again on every call until it is fixed.
+func (l *ConfigLoader) Load() (*Config, *tls.Config, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.config != nil && l.unchanged() {
+		return l.config, l.tlsConfig, nil
+	}
+
+	// files are stamped before being read, so a change while reading them is picked up by the next call
+	stamps := map[string]fileStamp{}
+	stamp, err := stat(l.path)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read web config")
+	}
+	stamps[l.path] = stamp
+	config, err := LoadConfig(l.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if c := config.TLSServerConfig; c != nil {
+		for _, path := range []string{c.CertFile, c.KeyFile, c.ClientCAFile} {
+			if path == "" {
+				continue
+			}
+			if stamps[path], err = stat(path); err != nil {
+				return nil, nil, errors.Wrap(err, "failed to read tls_server_config file")
+			}
+		}
+		if tlsConfig, err = c.tlsConfig(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	l.stamps, l.config, l.tlsConfig = stamps, config, tlsConfig
+	return config, tlsConfig, nil
+}
+
+// unchanged returns whether none of the files the cached config was read from changed since.
+func (l *ConfigLoader) unchanged() bool {
+	for path, stamp := range l.stamps {
+		current, err := stat(path)
+		if err != nil || !current.modTime.Equal(stamp.modTime) || current.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
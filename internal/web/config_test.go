@@ -0,0 +1,85 @@
+package web
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "web.yaml")
+	writeFile(t, configPath, `
+tls_server_config:
+  cert_file: cert.pem
+  key_file: /etc/ethereum_exporter/key.pem
+  client_ca_file: ca.pem
+basic_auth_users:
+  prometheus: $2y$10$QOauhQNbBCuQDKes6eFzPeMqBSjb7Mr5DUmpZ/VcEd00UAV/LDeSi
+`)
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := config.TLSServerConfig.CertFile, filepath.Join(dir, "cert.pem"); got != want {
+		t.Fatalf("got cert_file %q, want %q", got, want)
+	}
+	if got := config.TLSServerConfig.KeyFile; got != "/etc/ethereum_exporter/key.pem" {
+		t.Fatalf("got key_file %q, want it unchanged", got)
+	}
+	if clientAuth, _ := config.TLSServerConfig.clientAuth(); clientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("got client auth %v, want RequireAndVerifyClientCert", clientAuth)
+	}
+	if len(config.BasicAuthUsers) != 1 {
+		t.Fatalf("got %d users, want 1", len(config.BasicAuthUsers))
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":        "tls_config: {}",
+		"plain text password":  "basic_auth_users:\n  prometheus: secret",
+		"missing key file":     "tls_server_config:\n  cert_file: cert.pem",
+		"unknown auth type":    "tls_server_config:\n  cert_file: cert.pem\n  key_file: key.pem\n  client_auth_type: Always",
+		"verify without CA":    "tls_server_config:\n  cert_file: cert.pem\n  key_file: key.pem\n  client_auth_type: RequireAndVerifyClientCert",
+		"unknown TLS version":  "tls_server_config:\n  cert_file: cert.pem\n  key_file: key.pem\n  min_version: SSL30",
+		"malformed web config": "basic_auth_users: [",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "web.yaml")
+			writeFile(t, configPath, content)
+			if _, err := LoadConfig(configPath); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestConfigLoaderCaches(t *testing.T) {
+	dir := t.TempDir()
+	writeCertificate(t, dir)
+	configPath := filepath.Join(dir, "web.yaml")
+	writeFile(t, configPath, "tls_server_config:\n  cert_file: cert.pem\n  key_file: key.pem\n")
+
+	loader := NewConfigLoader(configPath)
+	config, tlsConfig, err := loader.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, againTLS, _ := loader.Load(); again != config || againTLS != tlsConfig {
+		t.Fatalf("expected the unchanged config to be cached")
+	}
+
+	// rotating the certificate reloads the TLS config, even though the web config file is unchanged
+	writeCertificate(t, dir)
+	if _, rotated, err := loader.Load(); err != nil || rotated == tlsConfig {
+		t.Fatalf("expected the TLS config to be reloaded, got error %v", err)
+	}
+
+	writeFile(t, configPath, "tls_server_config: [")
+	if _, _, err := loader.Load(); err == nil {
+		t.Fatalf("expected an error for the invalid config")
+	}
+}
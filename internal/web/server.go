@@ -0,0 +1,121 @@
+package web
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authCacheSize bounds the number of successful logins kept to skip bcrypt, which is slow by design, on every
+// scrape.
+const authCacheSize = 100
+
+// dummyHash is compared against for unknown users, so they take as long to reject as wrong passwords.
+var dummyHash = []byte("$2y$10$QOauhQNbBCuQDKes6eFzPeMqBSjb7Mr5DUmpZ/VcEd00UAV/LDeSi")
+
+// ListenAndServe serves handler on addr, with the TLS and basic auth settings of the web config file at
+// configPath. The file is checked for changes on every request and TLS handshake, so changes apply without a
+// restart, but enabling or disabling TLS does require one. An empty configPath serves plain HTTP without auth.
+func ListenAndServe(addr, configPath string, handler http.Handler) error {
+	if configPath == "" {
+		return http.ListenAndServe(addr, handler)
+	}
+	loader := NewConfigLoader(configPath)
+	_, tlsConfig, err := loader.Load()
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: NewAuthHandler(loader, handler),
+	}
+	if tlsConfig == nil {
+		return server.ListenAndServe()
+	}
+	server.TLSConfig = &tls.Config{GetConfigForClient: loader.GetConfigForClient}
+	return server.ListenAndServeTLS("", "")
+}
+
+// GetConfigForClient is a tls.Config callback returning the TLS settings of the web config file, as of the
+// handshake.
+func (l *ConfigLoader) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	_, tlsConfig, err := l.Load()
+	if err != nil {
+		log.Printf("Failed to reload web config: %v", err)
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return nil, errors.New("tls_server_config can't be removed without a restart")
+	}
+	return tlsConfig, nil
+}
+
+// AuthHandler requires the basic auth credentials of one of the users of a web config file, when it has any.
+type AuthHandler struct {
+	loader  *ConfigLoader
+	handler http.Handler
+
+	mutex sync.Mutex
+	// authenticated holds the SHA-256 of recently accepted credentials, along with the bcrypt hash they matched.
+	authenticated map[[sha256.Size]byte]bool
+}
+
+func NewAuthHandler(loader *ConfigLoader, handler http.Handler) *AuthHandler {
+	return &AuthHandler{
+		loader:        loader,
+		handler:       handler,
+		authenticated: map[[sha256.Size]byte]bool{},
+	}
+}
+
+func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	config, _, err := h.loader.Load()
+	if err != nil {
+		log.Printf("Failed to reload web config: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if len(config.BasicAuthUsers) > 0 {
+		user, password, ok := r.BasicAuth()
+		if !ok || !h.authenticate(config.BasicAuthUsers, user, password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ethereum_exporter"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+func (h *AuthHandler) authenticate(users map[string]string, user, password string) bool {
+	hash, known := users[user]
+	if !known {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	// the bcrypt hash is part of the key, so changing a password in the web config invalidates its entry
+	key := sha256.Sum256([]byte(user + "\x00" + hash + "\x00" + password))
+
+	h.mutex.Lock()
+	cached := h.authenticated[key]
+	h.mutex.Unlock()
+	if cached {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false
+	}
+	h.mutex.Lock()
+	if len(h.authenticated) >= authCacheSize {
+		h.authenticated = map[[sha256.Size]byte]bool{}
+	}
+	h.authenticated[key] = true
+	h.mutex.Unlock()
+	return true
+}
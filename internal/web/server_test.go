@@ -0,0 +1,178 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// writeFile writes content to path, moving its modification time forward when it was already written, so that a
+// rewrite of the same size within the resolution of the file system clock is still seen as a change.
+func writeFile(t *testing.T, path, content string) {
+	previous, statErr := os.Stat(path)
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("could not write %s: %v", path, err)
+	}
+	if statErr == nil {
+		modTime := previous.ModTime().Add(time.Second)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("could not touch %s: %v", path, err)
+		}
+	}
+}
+
+func hashPassword(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("could not hash the password: %v", err)
+	}
+	return string(hash)
+}
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and its key to dir, returning the certificate.
+func writeCertificate(t *testing.T, dir string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate a key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "exporter"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("could not create a certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("could not parse the certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("could not marshal the key: %v", err)
+	}
+	writeFile(t, filepath.Join(dir, "cert.pem"), string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	writeFile(t, filepath.Join(dir, "key.pem"), string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+	return cert
+}
+
+func TestAuthHandler(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "web.yaml")
+	writeFile(t, configPath, "")
+	server := httptest.NewServer(NewAuthHandler(NewConfigLoader(configPath), okHandler))
+	defer server.Close()
+
+	get := func(user, password string) int {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// without users, requests aren't authenticated
+	if got := get("", ""); got != http.StatusOK {
+		t.Fatalf("got status %d, want 200", got)
+	}
+
+	writeFile(t, configPath, fmt.Sprintf("basic_auth_users:\n  prometheus: %s\n", hashPassword(t, "secret")))
+	tests := []struct {
+		user, password string
+		status         int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"prometheus", "wrong", http.StatusUnauthorized},
+		{"grafana", "secret", http.StatusUnauthorized},
+		{"prometheus", "secret", http.StatusOK},
+		// cached
+		{"prometheus", "secret", http.StatusOK},
+	}
+	for _, test := range tests {
+		if got := get(test.user, test.password); got != test.status {
+			t.Fatalf("got status %d for %q:%q, want %d", got, test.user, test.password, test.status)
+		}
+	}
+
+	// changing the password invalidates the cached credentials
+	writeFile(t, configPath, fmt.Sprintf("basic_auth_users:\n  prometheus: %s\n", hashPassword(t, "rotated")))
+	if got := get("prometheus", "secret"); got != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401", got)
+	}
+	if got := get("prometheus", "rotated"); got != http.StatusOK {
+		t.Fatalf("got status %d, want 200", got)
+	}
+
+	// an invalid config fails requests rather than serving them unauthenticated
+	writeFile(t, configPath, "basic_auth_users: [")
+	if got := get("prometheus", "rotated"); got != http.StatusInternalServerError {
+		t.Fatalf("got status %d, want 500", got)
+	}
+}
+
+func TestConfigLoaderGetConfigForClient(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeCertificate(t, dir)
+	configPath := filepath.Join(dir, "web.yaml")
+	writeFile(t, configPath, "tls_server_config:\n  cert_file: cert.pem\n  key_file: key.pem\n")
+
+	server := httptest.NewUnstartedServer(okHandler)
+	server.TLS = &tls.Config{GetConfigForClient: NewConfigLoader(configPath).GetConfigForClient}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+
+	// client certificates are required from the next handshake on
+	writeFile(t, configPath, "tls_server_config:\n  cert_file: cert.pem\n  key_file: key.pem\n  client_ca_file: cert.pem\n")
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected a TLS handshake error")
+	}
+}
@@ -3,9 +3,13 @@
     'mysql-overview.json': (import 'dashboards/erc20.json'),
   },
 
+  prometheusAlerts+: importRules(importstr 'alerts/alerts.yaml'),
+
+  prometheusRules+: importRules(importstr 'rules/rules.yaml'),
+
   // Helper function to ensure that we don't override other rules, by forcing
   // the patching of the groups list, and not the overall rules object.
   local importRules(rules) = {
     groups+: std.native('parseYaml')(rules)[0].groups,
   },
-}
\ No newline at end of file
+}